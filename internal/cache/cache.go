@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+type cache struct {
+	config  config.CacheConfig
+	logger  types.Logger
+	entries map[string]types.CacheEntry
+	mu      sync.RWMutex
+}
+
+// NewCache loads the cache file, starting empty when it does not exist yet.
+func NewCache(config config.CacheConfig, logger types.Logger) (*cache, error) {
+	c := &cache{
+		config:  config,
+		logger:  logger,
+		entries: make(map[string]types.CacheEntry),
+	}
+
+	content, err := os.ReadFile(config.FilePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("caught err while reading cache file: %w", err)
+	}
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, &c.entries); err != nil {
+			return nil, fmt.Errorf("caught err while parsing cache file: %w", err)
+		}
+	}
+
+	c.logger.Infof("Cache loaded with %d entries from %s", len(c.entries), config.FilePath)
+	return c, nil
+}
+
+// Get returns the entry stored for url.
+func (c *cache) Get(url string) (types.CacheEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	entry, ok := c.entries[url]
+	return entry, ok
+}
+
+// Put stores the entry for url.
+func (c *cache) Put(url string, entry types.CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[url] = entry
+}
+
+// Close saves the cache, replacing the file atomically so an interrupted save keeps the old one.
+func (c *cache) Close() error {
+	c.mu.RLock()
+	content, err := json.Marshal(c.entries)
+	c.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.config.FilePath), filepath.Base(c.config.FilePath)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("caught err while saving cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("caught err while saving cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("caught err while saving cache: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), c.config.FilePath)
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	cacheConfig := config.CacheConfig{FilePath: filepath.Join(t.TempDir(), "cache.json")}
+
+	c, err := NewCache(cacheConfig, types.NewLoggerStub())
+	assert.NoError(t, err)
+
+	_, ok := c.Get("https://example.com/")
+	assert.False(t, ok)
+
+	entry := types.CacheEntry{
+		ETag:         `"v1"`,
+		LastModified: "Mon, 19 Oct 2026 10:00:00 GMT",
+		SHA256:       "digest",
+		UpdatedAt:    time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC),
+	}
+	c.Put("https://example.com/", entry)
+	assert.NoError(t, c.Close())
+
+	// The next run sees the entries saved by the previous one.
+	c, err = NewCache(cacheConfig, types.NewLoggerStub())
+	assert.NoError(t, err)
+
+	got, ok := c.Get("https://example.com/")
+	assert.True(t, ok)
+	assert.Equal(t, entry, got)
+
+	files, err := os.ReadDir(filepath.Dir(cacheConfig.FilePath))
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestNewCache_Corrupt(t *testing.T) {
+	cacheConfig := config.CacheConfig{FilePath: filepath.Join(t.TempDir(), "cache.json")}
+	assert.NoError(t, os.WriteFile(cacheConfig.FilePath, []byte("not json"), 0644))
+
+	c, err := NewCache(cacheConfig, types.NewLoggerStub())
+	assert.Error(t, err)
+	assert.Nil(t, c)
+}
@@ -4,14 +4,23 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
 
 	"github.com/go-playground/validator/v10"
 )
 
+const (
+	ManifestFileName    = "manifest.jsonl"
+	ArchiveFileBaseName = "downloads"
+)
+
 // NewConfig initializes and validates a new Config instance.
 func NewConfig() (*Config, error) {
 	config := &Config{}
-	config.build()
+	if err := config.build(); err != nil {
+		return nil, fmt.Errorf("caught err while building config: %w", err)
+	}
 
 	if err := validator.New().Struct(config); err != nil {
 		return nil, fmt.Errorf("caught err while building config: %w", err)
@@ -23,32 +32,165 @@ func NewConfig() (*Config, error) {
 	return config, nil
 }
 
-func (c *Config) build() {
+func (c *Config) build() error {
 	c.buildCmdLineArgs()
+	if err := c.loadConfigFile(); err != nil {
+		return err
+	}
 	c.buildReadConfig()
-	c.buildWriteConfig()
+	c.buildDownloadConfig()
+	if err := c.buildWriteConfig(); err != nil {
+		return err
+	}
+	c.buildReportConfig()
+	c.buildMetricsConfig()
+	c.buildProgressConfig()
+	c.buildTracingConfig()
+	c.buildLogConfig()
+	return c.buildFilterConfig()
 }
 
 func (c *Config) buildCmdLineArgs() {
 	filepath := flag.String("csv-file", "", "CSV File path")
 	outDir := flag.String("out-dir", "", "Directory to store downloaded files")
+	configFile := flag.String("config", "", "Optional JSON file with additional configuration")
+	reportFile := flag.String("report-file", "", "Optional file to write the per-URL JSON lines report to")
+	hostsFile := flag.String("hosts-report-file", "", "Optional JSON file to write the results of every host to at the end of the run")
+	cacheFile := flag.String("cache-file", "", "Optional file caching ETag/Last-Modified per URL for conditional requests")
+	maxSize := flag.Int64("max-size", 0, "Maximum response size per URL in bytes, 0 for no limit")
+	manifest := flag.Bool("manifest", false, "Append every written file to manifest.jsonl in the output directory")
+	archive := flag.String("archive", "", "Optional archive format (tar, tar.gz or zip) to bundle all downloads into")
+	metrics := flag.String("metrics-addr", "", "Optional address, such as :9090, to serve Prometheus metrics on")
+	noProgress := flag.Bool("no-progress", false, "Log stats periodically instead of showing live progress in a terminal")
+	traceFile := flag.String("trace-file", "", "Optional file to write OpenTelemetry spans to as JSON lines")
+	otlp := flag.String("otlp-endpoint", "", "Optional OTLP/HTTP collector address, such as localhost:4318, to send OpenTelemetry spans to")
+	logLevel := flag.String("log-level", "", "Optional lowest level to log: debug, info (default), warn or error")
+	logFormat := flag.String("log-format", "", "Optional log format: console (default) or json")
+	logFile := flag.String("log-file", "", "Optional file to log to instead of stderr, rotated as it grows")
 
 	flag.Parse()
 
 	c.Cmd = cmdLineArgs{
-		FilePath: *filepath,
-		OutDir:   *outDir,
+		FilePath:   *filepath,
+		OutDir:     *outDir,
+		ConfigFile: *configFile,
+		ReportFile: *reportFile,
+		HostsFile:  *hostsFile,
+		Manifest:   *manifest,
+		CacheFile:  *cacheFile,
+		MaxSize:    *maxSize,
+		Archive:    *archive,
+		Metrics:    *metrics,
+		NoProgress: *noProgress,
+		TraceFile:  *traceFile,
+		OTLP:       *otlp,
+		LogLevel:   *logLevel,
+		LogFormat:  *logFormat,
+		LogFile:    *logFile,
 	}
 }
 
+// loadConfigFile fills the config from the JSON file given on the command line, if any.
+// Command line arguments are applied afterwards and take precedence.
+func (c *Config) loadConfigFile() error {
+	if c.Cmd.ConfigFile == "" {
+		return nil
+	}
+
+	content, err := os.ReadFile(c.Cmd.ConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cmd := c.Cmd
+	if err := json.Unmarshal(content, c); err != nil {
+		return fmt.Errorf("failed to parse config file: %w", err)
+	}
+	c.Cmd = cmd
+
+	return nil
+}
+
 func (c *Config) buildReadConfig() {
-	c.Read = ReadConfig{
-		FilePath: c.Cmd.FilePath,
+	c.Read.FilePath = c.Cmd.FilePath
+}
+
+func (c *Config) buildDownloadConfig() {
+	if c.Cmd.CacheFile != "" {
+		c.Download.Cache.FilePath = c.Cmd.CacheFile
+	}
+	if c.Cmd.MaxSize != 0 {
+		c.Download.Limits.MaxSize = c.Cmd.MaxSize
+	}
+}
+
+func (c *Config) buildWriteConfig() error {
+	c.Write.WriteDir = c.Cmd.OutDir
+	if c.Cmd.Manifest && c.Write.ManifestFile == "" {
+		c.Write.ManifestFile = filepath.Join(c.Write.WriteDir, ManifestFileName)
+	}
+	if c.Cmd.Archive != "" {
+		c.Write.Archive.Format = c.Cmd.Archive
 	}
+	if c.Write.Archive.Format != "" && c.Write.Archive.FilePath == "" {
+		c.Write.Archive.FilePath = filepath.Join(c.Write.WriteDir, ArchiveFileBaseName+"."+c.Write.Archive.Format)
+	}
+	if c.Write.Archive.Format != "" && c.Write.S3.Bucket != "" {
+		return fmt.Errorf("archive output and S3 output cannot be combined")
+	}
+	return nil
+}
+
+func (c *Config) buildFilterConfig() error {
+	c.Filter.Dedup.ManifestFile = c.Write.ManifestFile
+	if c.Filter.Dedup.Mode == DedupModeManifest && c.Filter.Dedup.ManifestFile == "" {
+		return fmt.Errorf("dedup mode %s requires a manifest file", DedupModeManifest)
+	}
+	return nil
 }
 
-func (c *Config) buildWriteConfig() {
-	c.Write = WriteConfig{
-		WriteDir: c.Cmd.OutDir,
+func (c *Config) buildReportConfig() {
+	if c.Cmd.ReportFile != "" {
+		c.Report.FilePath = c.Cmd.ReportFile
+	}
+	if c.Cmd.HostsFile != "" {
+		c.Report.HostsFilePath = c.Cmd.HostsFile
+	}
+}
+
+func (c *Config) buildMetricsConfig() {
+	if c.Cmd.Metrics != "" {
+		c.Metrics.Address = c.Cmd.Metrics
+	}
+}
+
+func (c *Config) buildProgressConfig() {
+	if c.Cmd.NoProgress {
+		c.Progress.Disabled = true
+	}
+}
+
+// buildTracingConfig selects the exporter given on the command line. A trace file wins over an
+// OTLP endpoint when both are given.
+func (c *Config) buildTracingConfig() {
+	if c.Cmd.OTLP != "" {
+		c.Tracing.Exporter = TracingExporterOTLP
+		c.Tracing.Endpoint = c.Cmd.OTLP
+	}
+	if c.Cmd.TraceFile != "" {
+		c.Tracing.Exporter = TracingExporterFile
+		c.Tracing.FilePath = c.Cmd.TraceFile
+	}
+}
+
+func (c *Config) buildLogConfig() {
+	if c.Cmd.LogLevel != "" {
+		c.Log.Level = c.Cmd.LogLevel
+	}
+	if c.Cmd.LogFormat != "" {
+		c.Log.Format = c.Cmd.LogFormat
+	}
+	if c.Cmd.LogFile != "" {
+		c.Log.FilePath = c.Cmd.LogFile
 	}
 }
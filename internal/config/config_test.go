@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,6 +13,7 @@ import (
 
 const csvFileArg = "csv-file"
 const outDirArg = "out-dir"
+const configArg = "config"
 
 func resetFlags() {
 	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
@@ -55,3 +57,235 @@ func TestNewConfigError(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, config)
 }
+
+func TestNewConfigFromFile(t *testing.T) {
+	resetFlags()
+
+	testCSVFile := "/path/to/dummy/dir/test.csv"
+	testOutDir := "/path/to/dummy/dir/output"
+	testConfigFile := filepath.Join(t.TempDir(), "config.json")
+
+	content := `{
+		"write": {"writeDir": "/ignored/in/favour/of/flag"},
+		"download": {
+			"tls": {
+				"rootCAFiles": ["/etc/ssl/private-ca.pem"],
+				"minVersion": "1.2",
+				"hosts": {
+					"mirror.internal": {"certFile": "client.pem", "keyFile": "client-key.pem"}
+				}
+			}
+		}
+	}`
+	assert.NoError(t, os.WriteFile(testConfigFile, []byte(content), 0644))
+
+	os.Args = []string{
+		"dummy",
+		fmt.Sprintf("--%s=%s", csvFileArg, testCSVFile),
+		fmt.Sprintf("--%s=%s", outDirArg, testOutDir),
+		fmt.Sprintf("--%s=%s", configArg, testConfigFile),
+	}
+
+	config, err := NewConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, testOutDir, config.Write.WriteDir)
+	assert.Equal(t, []string{"/etc/ssl/private-ca.pem"}, config.Download.TLS.RootCAFiles)
+	assert.Equal(t, "1.2", config.Download.TLS.MinVersion)
+	assert.Equal(t, "client.pem", config.Download.TLS.Hosts["mirror.internal"].CertFile)
+}
+
+func TestNewConfigFromFileError(t *testing.T) {
+	resetFlags()
+
+	testConfigFile := filepath.Join(t.TempDir(), "config.json")
+	content := `{"download": {"tls": {"minVersion": "0.9"}}}`
+	assert.NoError(t, os.WriteFile(testConfigFile, []byte(content), 0644))
+
+	os.Args = []string{
+		"dummy",
+		fmt.Sprintf("--%s=%s", csvFileArg, "/path/to/dummy/dir/test.csv"),
+		fmt.Sprintf("--%s=%s", outDirArg, "/path/to/dummy/dir/output"),
+		fmt.Sprintf("--%s=%s", configArg, testConfigFile),
+	}
+
+	config, err := NewConfig()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+}
+
+func TestNewConfigManifest(t *testing.T) {
+	resetFlags()
+
+	testOutDir := "/path/to/dummy/dir/output"
+	testConfigFile := filepath.Join(t.TempDir(), "config.json")
+	content := `{"filter": {"dedup": {"mode": "manifest"}}}`
+	assert.NoError(t, os.WriteFile(testConfigFile, []byte(content), 0644))
+
+	os.Args = []string{
+		"dummy",
+		fmt.Sprintf("--%s=%s", csvFileArg, "/path/to/dummy/dir/test.csv"),
+		fmt.Sprintf("--%s=%s", outDirArg, testOutDir),
+		fmt.Sprintf("--%s=%s", configArg, testConfigFile),
+		"--manifest",
+	}
+
+	config, err := NewConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(testOutDir, ManifestFileName), config.Write.ManifestFile)
+	assert.Equal(t, config.Write.ManifestFile, config.Filter.Dedup.ManifestFile)
+
+	// Skipping URLs of previous runs is impossible without a manifest.
+	resetFlags()
+	os.Args = os.Args[:len(os.Args)-1]
+
+	config, err = NewConfig()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+}
+
+func TestNewConfigArchive(t *testing.T) {
+	resetFlags()
+
+	testOutDir := "/path/to/dummy/dir/output"
+	os.Args = []string{
+		"dummy",
+		fmt.Sprintf("--%s=%s", csvFileArg, "/path/to/dummy/dir/test.csv"),
+		fmt.Sprintf("--%s=%s", outDirArg, testOutDir),
+		"--archive=tar.gz",
+	}
+
+	config, err := NewConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "tar.gz", config.Write.Archive.Format)
+	assert.Equal(t, filepath.Join(testOutDir, "downloads.tar.gz"), config.Write.Archive.FilePath)
+
+	resetFlags()
+	os.Args[len(os.Args)-1] = "--archive=rar"
+
+	config, err = NewConfig()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+}
+
+func TestNewConfigS3(t *testing.T) {
+	resetFlags()
+
+	testConfigFile := filepath.Join(t.TempDir(), "config.json")
+	content := `{"write": {"s3": {"endpoint": "http://localhost:9000", "bucket": "downloads", "pathStyle": true}}}`
+	assert.NoError(t, os.WriteFile(testConfigFile, []byte(content), 0644))
+
+	os.Args = []string{
+		"dummy",
+		fmt.Sprintf("--%s=%s", csvFileArg, "/path/to/dummy/dir/test.csv"),
+		fmt.Sprintf("--%s=%s", outDirArg, "/path/to/dummy/dir/output"),
+		fmt.Sprintf("--%s=%s", configArg, testConfigFile),
+	}
+
+	config, err := NewConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "downloads", config.Write.S3.Bucket)
+
+	// Downloads go either to an archive or to the bucket.
+	resetFlags()
+	os.Args = append(os.Args, "--archive=zip")
+
+	config, err = NewConfig()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+}
+
+func TestNewConfigMetrics(t *testing.T) {
+	resetFlags()
+
+	os.Args = []string{
+		"dummy",
+		fmt.Sprintf("--%s=%s", csvFileArg, "/path/to/dummy/dir/test.csv"),
+		fmt.Sprintf("--%s=%s", outDirArg, "/path/to/dummy/dir/output"),
+		"--metrics-addr=:9090",
+	}
+
+	config, err := NewConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, ":9090", config.Metrics.Address)
+
+	resetFlags()
+	os.Args[len(os.Args)-1] = "--metrics-addr=9090"
+
+	config, err = NewConfig()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+}
+
+func TestNewConfigTracing(t *testing.T) {
+	args := []string{
+		"dummy",
+		fmt.Sprintf("--%s=%s", csvFileArg, "/path/to/dummy/dir/test.csv"),
+		fmt.Sprintf("--%s=%s", outDirArg, "/path/to/dummy/dir/output"),
+	}
+
+	resetFlags()
+	os.Args = append(args, "--otlp-endpoint=localhost:4318")
+	config, err := NewConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, TracingExporterOTLP, config.Tracing.Exporter)
+	assert.Equal(t, "localhost:4318", config.Tracing.Endpoint)
+
+	resetFlags()
+	os.Args = append(args, "--otlp-endpoint=localhost:4318", "--trace-file=/tmp/spans.jsonl")
+	config, err = NewConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, TracingExporterFile, config.Tracing.Exporter)
+	assert.Equal(t, "/tmp/spans.jsonl", config.Tracing.FilePath)
+
+	resetFlags()
+	os.Args = append(args, "--otlp-endpoint=4318")
+	config, err = NewConfig()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+}
+
+func TestNewConfigReport(t *testing.T) {
+	args := []string{
+		"dummy",
+		fmt.Sprintf("--%s=%s", csvFileArg, "/path/to/dummy/dir/test.csv"),
+		fmt.Sprintf("--%s=%s", outDirArg, "/path/to/dummy/dir/output"),
+	}
+
+	resetFlags()
+	os.Args = args
+	config, err := NewConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, ReportConfig{}, config.Report)
+
+	resetFlags()
+	os.Args = append(args, "--report-file=/tmp/report.jsonl", "--hosts-report-file=/tmp/hosts.json")
+	config, err = NewConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, ReportConfig{FilePath: "/tmp/report.jsonl", HostsFilePath: "/tmp/hosts.json"}, config.Report)
+}
+
+func TestNewConfigLog(t *testing.T) {
+	args := []string{
+		"dummy",
+		fmt.Sprintf("--%s=%s", csvFileArg, "/path/to/dummy/dir/test.csv"),
+		fmt.Sprintf("--%s=%s", outDirArg, "/path/to/dummy/dir/output"),
+	}
+
+	resetFlags()
+	os.Args = append(args, "--log-level=debug", "--log-format=json", "--log-file=/tmp/app.log")
+	config, err := NewConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, LogConfig{Level: "debug", Format: LogFormatJSON, FilePath: "/tmp/app.log"}, config.Log)
+
+	resetFlags()
+	os.Args = append(args, "--log-level=verbose")
+	config, err = NewConfig()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+
+	resetFlags()
+	os.Args = append(args, "--log-format=text")
+	config, err = NewConfig()
+	assert.Error(t, err)
+	assert.Nil(t, config)
+}
@@ -1,20 +1,296 @@
 package config
 
 type Config struct {
-	Read  ReadConfig  `json:"read" validate:"required"`
-	Write WriteConfig `json:"write" validate:"required"`
-	Cmd   cmdLineArgs `json:"cmd" validate:"required"`
+	Read     ReadConfig     `json:"read" validate:"required"`
+	Filter   FilterConfig   `json:"filter"`
+	Download DownloadConfig `json:"download"`
+	Write    WriteConfig    `json:"write" validate:"required"`
+	Report   ReportConfig   `json:"report"`
+	Metrics  MetricsConfig  `json:"metrics"`
+	Progress ProgressConfig `json:"progress"`
+	Tracing  TracingConfig  `json:"tracing"`
+	Log      LogConfig      `json:"log"`
+	Cmd      cmdLineArgs    `json:"cmd" validate:"required"`
 }
 
 type ReadConfig struct {
 	FilePath string `json:"filePath" validate:"required"`
 }
 
+// FilterConfig restricts which URLs are downloaded. Deny rules are checked first, then every
+// non-empty allow list has to match.
+type FilterConfig struct {
+	// AllowHosts and DenyHosts match the host or any of its subdomains.
+	AllowHosts []string `json:"allowHosts"`
+	DenyHosts  []string `json:"denyHosts"`
+	// AllowPatterns and DenyPatterns are regular expressions matched against the full URL.
+	AllowPatterns []string    `json:"allowPatterns"`
+	DenyPatterns  []string    `json:"denyPatterns"`
+	AllowSchemes  []string    `json:"allowSchemes"`
+	AllowPorts    []int       `json:"allowPorts" validate:"dive,min=1,max=65535"`
+	Dedup         DedupConfig `json:"dedup"`
+}
+
+const (
+	DedupModeNone     = "none"
+	DedupModeRun      = "run"
+	DedupModeManifest = "manifest"
+)
+
+// DedupConfig controls how repeated URLs are skipped. URLs are compared after normalization.
+type DedupConfig struct {
+	// Mode is "run" (default) to skip repeats within the run, "manifest" to also skip URLs
+	// found in the output manifest of previous runs, or "none".
+	Mode string `json:"mode" validate:"omitempty,oneof=none run manifest"`
+	// MaxMemoryEntries is the number of URLs kept in an exact in-memory set before switching
+	// to a bloom filter, which uses bounded memory but may skip a small share of unique URLs.
+	MaxMemoryEntries int `json:"maxMemoryEntries" validate:"gte=0"`
+	// ExpectedEntries and FalsePositiveRate size the bloom filter. Zero values use the defaults.
+	ExpectedEntries   int     `json:"expectedEntries" validate:"gte=0"`
+	FalsePositiveRate float64 `json:"falsePositiveRate" validate:"gte=0,lt=1"`
+	// ManifestFile is the manifest read in "manifest" mode, taken from the write config.
+	ManifestFile string `json:"manifestFile"`
+}
+
+type DownloadConfig struct {
+	TLS      TLSConfig      `json:"tls"`
+	Redirect RedirectConfig `json:"redirect"`
+	SSRF     SSRFConfig     `json:"ssrf"`
+	Cache    CacheConfig    `json:"cache"`
+	Limits   LimitsConfig   `json:"limits"`
+}
+
+// LimitsConfig bounds what the downloader accepts. Zero sizes and empty lists mean no limit.
+type LimitsConfig struct {
+	// MaxSize is the largest response body accepted for a single URL, in bytes.
+	MaxSize int64 `json:"maxSize" validate:"gte=0"`
+	// MaxTotalSize is the number of body bytes the whole run may download.
+	MaxTotalSize int64 `json:"maxTotalSize" validate:"gte=0"`
+	// AllowContentTypes and DenyContentTypes hold media types such as "text/html" or "image/*".
+	AllowContentTypes []string `json:"allowContentTypes"`
+	DenyContentTypes  []string `json:"denyContentTypes"`
+}
+
+// CacheConfig controls the ETag/Last-Modified cache used for conditional requests,
+// which is disabled when FilePath is empty.
+type CacheConfig struct {
+	FilePath string `json:"filePath"`
+}
+
+// TLSConfig controls how the downloader verifies servers and authenticates itself to them.
+type TLSConfig struct {
+	// RootCAFiles are PEM bundles trusted in addition to the system roots.
+	RootCAFiles []string `json:"rootCAFiles"`
+	// MinVersion is the lowest accepted TLS version, one of 1.0, 1.1, 1.2 or 1.3.
+	MinVersion string `json:"minVersion" validate:"omitempty,oneof=1.0 1.1 1.2 1.3"`
+	// Hosts holds per-host overrides keyed by "host" or "host:port".
+	Hosts map[string]HostTLSConfig `json:"hosts" validate:"dive"`
+}
+
+type HostTLSConfig struct {
+	CertFile           string `json:"certFile" validate:"required_with=KeyFile"`
+	KeyFile            string `json:"keyFile" validate:"required_with=CertFile"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+}
+
+// RedirectConfig controls which redirects the downloader follows.
+type RedirectConfig struct {
+	// MaxRedirects is the number of redirects followed per URL, 10 when unset and none when 0.
+	MaxRedirects *int `json:"maxRedirects" validate:"omitempty,gte=0"`
+	// ForbidDowngrade rejects redirects from https to http.
+	ForbidDowngrade bool `json:"forbidDowngrade"`
+	// ForbidCrossHost rejects redirects to a host other than the one in the CSV.
+	ForbidCrossHost bool `json:"forbidCrossHost"`
+}
+
+// SSRFConfig controls the guard that stops downloads from reaching private, loopback,
+// link-local and other internal addresses.
+type SSRFConfig struct {
+	// Disabled turns the guard off entirely.
+	Disabled bool `json:"disabled"`
+	// AllowHosts are host names that may resolve to internal addresses.
+	AllowHosts []string `json:"allowHosts"`
+	// AllowCIDRs are internal address ranges that may be dialed.
+	AllowCIDRs []string `json:"allowCIDRs" validate:"dive,cidr"`
+}
+
 type WriteConfig struct {
 	WriteDir string `json:"writeDir" validate:"required"`
+	// ManifestFile is the JSON lines file every written download is appended to, disabled when empty.
+	ManifestFile string `json:"manifestFile"`
+	// Naming picks the file name of a download: "uuid" (default), "sha256" of the content, or "url"
+	// derived from the host and path of the URL.
+	Naming string `json:"naming" validate:"omitempty,oneof=uuid sha256 url"`
+	// Layout arranges files in directories: "flat" (default) in WriteDir itself, "hash" in two levels
+	// named by a hash of the file name, "host" mirroring host and path of the URL, or "date" by the
+	// day written. Rows may name their directory in a dest_dir column instead.
+	Layout string `json:"layout" validate:"omitempty,oneof=flat hash host date"`
+	// ExistingFile decides what happens when the output file already exists: "overwrite" (default),
+	// "skip", "rename" to a free name with a numeric suffix, or "checksum" to keep the file when its
+	// content matches and overwrite it otherwise. Rows may override it in an existing_file column.
+	ExistingFile string `json:"existingFile" validate:"omitempty,oneof=overwrite skip rename checksum"`
+	// Concurrency is the number of files written or uploaded in parallel, 4 when unset. Archive output
+	// is always written by a single worker.
+	Concurrency int `json:"concurrency" validate:"gte=0"`
+	// QueueSize is the number of downloads waiting for a writer before downloaders block, 100 when unset.
+	QueueSize int `json:"queueSize" validate:"gte=0"`
+	// Fsync flushes every written file and its directory entry to disk before it counts as written.
+	Fsync bool `json:"fsync"`
+	// Sidecar writes a <file>.meta.json next to every output file, holding the source URL, response
+	// status and headers, timestamps, digest and size.
+	Sidecar bool          `json:"sidecar"`
+	Decode  DecodeConfig  `json:"decode"`
+	Archive ArchiveConfig `json:"archive"`
+	S3      S3Config      `json:"s3"`
+	Space   SpaceConfig   `json:"space"`
+}
+
+// SpaceConfig guards the output against filling up the disk or exceeding a quota. Zero values disable a check.
+type SpaceConfig struct {
+	// MinFreeBytes is the low-water mark of free space on WriteDir. Downloads pause while less is free
+	// and writes that would go below it fail.
+	MinFreeBytes int64 `json:"minFreeBytes" validate:"gte=0"`
+	// MaxPauseSeconds is how long downloads stay paused waiting for space before the run stops, 600 when unset.
+	MaxPauseSeconds int `json:"maxPauseSeconds" validate:"gte=0"`
+	// MaxTotalBytes is the number of bytes the run may write. The run stops once it is used up.
+	MaxTotalBytes int64 `json:"maxTotalBytes" validate:"gte=0"`
+}
+
+const (
+	LayoutFlat = "flat"
+	LayoutHash = "hash"
+	LayoutHost = "host"
+	LayoutDate = "date"
+)
+
+const (
+	ExistingFileOverwrite = "overwrite"
+	ExistingFileSkip      = "skip"
+	ExistingFileRename    = "rename"
+	ExistingFileChecksum  = "checksum"
+)
+
+const (
+	NamingUUID   = "uuid"
+	NamingSHA256 = "sha256"
+	NamingURL    = "url"
+)
+
+// ArchiveConfig bundles all downloads into a single archive instead of individual files in WriteDir.
+type ArchiveConfig struct {
+	// Format is one of "tar", "tar.gz" or "zip". Archiving is disabled when empty.
+	Format string `json:"format" validate:"omitempty,oneof=tar tar.gz zip"`
+	// FilePath is the archive to create, downloads.<format> in WriteDir when empty.
+	FilePath string `json:"filePath"`
+}
+
+// DecodeConfig controls how compressed payloads are unpacked before they are written.
+type DecodeConfig struct {
+	// ContentEncoding decodes gzip, deflate and zstd Content-Encoding left in place by the transport.
+	ContentEncoding bool `json:"contentEncoding"`
+	// Decompress writes the decompressed content of single-file gzip and zstd payloads.
+	Decompress bool `json:"decompress"`
+	// Extract unpacks tar, tar.gz, tar.zst and zip payloads into a directory per URL.
+	Extract bool `json:"extract"`
+	// MaxSize bounds the decoded size of a single payload, including all extracted files.
+	MaxSize int64 `json:"maxSize" validate:"gte=0"`
+	// MaxFiles bounds the number of entries extracted from a single archive.
+	MaxFiles int `json:"maxFiles" validate:"gte=0"`
+}
+
+// S3Config uploads all downloads to an S3-compatible bucket instead of WriteDir, which is disabled
+// when Bucket is empty. Credentials are read from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and
+// AWS_SESSION_TOKEN so that they never end up in a config file.
+type S3Config struct {
+	// Endpoint is the base URL of the service, https://s3.<region>.amazonaws.com when empty.
+	Endpoint string `json:"endpoint" validate:"omitempty,url"`
+	// Region is used for signing requests, us-east-1 when empty.
+	Region string `json:"region"`
+	Bucket string `json:"bucket"`
+	// Prefix is prepended to every object key.
+	Prefix string `json:"prefix"`
+	// PathStyle addresses the bucket in the path instead of the host name, as most stand-ins expect.
+	PathStyle bool `json:"pathStyle"`
+	// PartSize is the size of multipart upload parts, 8 MiB when unset. Bodies larger than one
+	// part are uploaded in parts.
+	PartSize int64 `json:"partSize" validate:"omitempty,min=5242880"`
+	// RequestTimeoutSeconds bounds every request to the service, a single part of a multipart
+	// upload included, 300 when unset.
+	RequestTimeoutSeconds int `json:"requestTimeoutSeconds" validate:"gte=0"`
+}
+
+// ReportConfig controls the per-URL report, which is disabled when FilePath is empty, and the
+// per-host report, which is disabled when HostsFilePath is empty.
+type ReportConfig struct {
+	FilePath string `json:"filePath"`
+	// HostsFilePath is the JSON file the results of every host are written to at the end of the run.
+	HostsFilePath string `json:"hostsFilePath"`
+}
+
+// MetricsConfig controls the Prometheus endpoint, which is disabled when Address is empty.
+type MetricsConfig struct {
+	// Address is the host:port the /metrics endpoint listens on, such as ":9090".
+	Address string `json:"address" validate:"omitempty,hostname_port"`
+}
+
+// ProgressConfig controls the live progress view, which is only shown when stdout is a terminal.
+type ProgressConfig struct {
+	Disabled bool `json:"disabled"`
+}
+
+const (
+	TracingExporterOTLP = "otlp"
+	TracingExporterFile = "file"
+)
+
+// TracingConfig controls the OpenTelemetry spans following every job through the pipeline.
+// Tracing is disabled when Exporter is empty.
+type TracingConfig struct {
+	// Exporter is otlp to send spans to Endpoint, or file to write them to FilePath as JSON lines.
+	Exporter string `json:"exporter" validate:"omitempty,oneof=otlp file"`
+	// Endpoint is the host:port of an OTLP/HTTP collector, such as "localhost:4318".
+	Endpoint string `json:"endpoint" validate:"required_if=Exporter otlp,omitempty,hostname_port"`
+	// Insecure sends spans to Endpoint over plain HTTP instead of HTTPS.
+	Insecure bool   `json:"insecure"`
+	FilePath string `json:"filePath" validate:"required_if=Exporter file"`
+}
+
+const (
+	LogFormatConsole = "console"
+	LogFormatJSON    = "json"
+)
+
+// LogConfig controls the level, format and destination of the logs, which go to stderr unless a
+// FilePath is given.
+type LogConfig struct {
+	// Level is the lowest level logged: debug, info, warn or error. Defaults to info.
+	Level string `json:"level" validate:"omitempty,oneof=debug info warn error"`
+	// Format is console for human readable lines or json for one object per line. Defaults to console.
+	Format   string `json:"format" validate:"omitempty,oneof=console json"`
+	FilePath string `json:"filePath"`
+	// MaxSizeMB is the size the log file is rotated at. Defaults to 100.
+	MaxSizeMB int `json:"maxSizeMB" validate:"min=0"`
+	// MaxBackups and MaxAgeDays bound how many rotated files are kept and for how long, 0 for no limit.
+	MaxBackups int  `json:"maxBackups" validate:"min=0"`
+	MaxAgeDays int  `json:"maxAgeDays" validate:"min=0"`
+	Compress   bool `json:"compress"`
 }
 
 type cmdLineArgs struct {
-	FilePath string `json:"filePath" validate:"required"`
-	OutDir   string `json:"outDir" validate:"required"`
+	FilePath   string `json:"filePath" validate:"required"`
+	OutDir     string `json:"outDir" validate:"required"`
+	ConfigFile string `json:"configFile"`
+	ReportFile string `json:"reportFile"`
+	HostsFile  string `json:"hostsFile"`
+	Manifest   bool   `json:"manifest"`
+	CacheFile  string `json:"cacheFile"`
+	MaxSize    int64  `json:"maxSize"`
+	Archive    string `json:"archive"`
+	Metrics    string `json:"metrics"`
+	NoProgress bool   `json:"noProgress"`
+	TraceFile  string `json:"traceFile"`
+	OTLP       string `json:"otlp"`
+	LogLevel   string `json:"logLevel"`
+	LogFormat  string `json:"logFormat"`
+	LogFile    string `json:"logFile"`
 }
@@ -1,13 +1,21 @@
 package csvreader
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/puruabhi/jfrog/home-assignment/internal/config"
 	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 //go:generate mockgen -destination=./mocks/mock_csv_reader.go -source=csv_reader.go -package=mocks .
@@ -24,12 +32,14 @@ type csvReader struct {
 	reader     CSVReadable
 	fileReader FileReadable
 	logger     types.Logger
-	urls       chan string
-	readUrls   int32
+	tracer     types.Traceable
+	urls       chan *types.Job
+	readUrls   atomic.Int32
+	totalUrls  atomic.Int32
 }
 
 // NewCSVReader initializes a new csvReader instance and starts fetching URLs.
-func NewCSVReader(config config.ReadConfig, logger types.Logger, urlChan chan string) (*csvReader, error) {
+func NewCSVReader(config config.ReadConfig, logger types.Logger, tracer types.Traceable, urlChan chan *types.Job) (*csvReader, error) {
 	fileReader, err := os.Open(config.FilePath)
 	if err != nil {
 		return nil, fmt.Errorf("caught err while opening file: %w", err)
@@ -41,9 +51,12 @@ func NewCSVReader(config config.ReadConfig, logger types.Logger, urlChan chan st
 		reader:     csvFileReader,
 		fileReader: fileReader,
 		logger:     logger,
+		tracer:     tracer,
 		urls:       urlChan,
 	}
+	csv.totalUrls.Store(-1)
 
+	go csv.countURLs()
 	go csv.fetchURLs()
 
 	csv.logger.Infof("CSV reader started")
@@ -62,6 +75,7 @@ func (r *csvReader) fetchURLs() {
 	r.logger.Debugf("CSV header: %+v\n", header)
 
 	for {
+		start := time.Now()
 		url, err := r.read()
 		if err != nil {
 			if err != io.EOF {
@@ -70,12 +84,39 @@ func (r *csvReader) fetchURLs() {
 			return
 		}
 
-		r.readUrls++
-		r.logger.Debugf("URL: %s\n", url[0])
-		r.urls <- url[0]
+		row := r.readUrls.Add(1)
+		job := &types.Job{ID: uuid.New().String(), Row: int(row), URL: url[0], Fields: fields(header, url)}
+		job.Ctx = r.startJob(job, start)
+		r.logger.With(job.LogFields()...).Debugf("Read URL")
+		r.urls <- job
 	}
 }
 
+// startJob starts the span of a job, read since start, which stays open until the job ends.
+func (r *csvReader) startJob(job *types.Job, start time.Time) context.Context {
+	ctx, _ := r.tracer.Start(context.Background(), "job", trace.WithTimestamp(start), trace.WithAttributes(
+		attribute.String("job.id", job.ID), attribute.Int("job.row", job.Row), semconv.URLFull(job.URL)))
+	_, read := r.tracer.Start(ctx, "read", trace.WithTimestamp(start))
+	read.End()
+	return ctx
+}
+
+// fields maps the columns after the URL to their header names, skipping columns without a name.
+func fields(header, record []string) map[string]string {
+	var fields map[string]string
+	for i := 1; i < len(record) && i < len(header); i++ {
+		name := strings.ToLower(strings.TrimSpace(header[i]))
+		if name == "" {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		fields[name] = strings.TrimSpace(record[i])
+	}
+	return fields
+}
+
 // read reads a single record from the CSV file.
 func (r *csvReader) read() ([]string, error) {
 	if r.reader == nil {
@@ -93,5 +134,42 @@ func (r *csvReader) Close() error {
 }
 
 func (r *csvReader) GetReadURLs() int32 {
-	return r.readUrls
+	return r.readUrls.Load()
+}
+
+func (r *csvReader) GetTotalURLs() int32 {
+	return r.totalUrls.Load()
+}
+
+// countURLs counts the records after the header in a pass of its own over the file, so that
+// progress can be shown against the total. The total stays unknown when the file cannot be read.
+func (r *csvReader) countURLs() {
+	total, err := countRecords(r.config.FilePath)
+	if err != nil {
+		r.logger.Warnf("Failed to count URLs in csv file: %s", err)
+		return
+	}
+	r.totalUrls.Store(total)
+}
+
+func countRecords(path string) (int32, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("caught err while opening file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.ReuseRecord = true
+	var records int32
+	for {
+		if _, err := reader.Read(); err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, fmt.Errorf("caught err while reading file: %w", err)
+		}
+		records++
+	}
+	// The header is not a URL.
+	return max(records-1, 0), nil
 }
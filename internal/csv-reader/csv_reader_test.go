@@ -3,9 +3,13 @@ package csvreader
 import (
 	"errors"
 	"io"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
 	"github.com/puruabhi/jfrog/home-assignment/internal/csv-reader/mocks"
 	"github.com/puruabhi/jfrog/home-assignment/internal/types"
 	"github.com/stretchr/testify/assert"
@@ -18,12 +22,13 @@ func TestFetchURLs(t *testing.T) {
 	mockCSVReader := mocks.NewMockCSVReadable(ctrl)
 	mockFileReader := mocks.NewMockFileReadable(ctrl)
 	logger := types.NewLoggerStub()
-	urlChan := make(chan string, 10)
+	urlChan := make(chan *types.Job, 10)
 
 	csv := &csvReader{
 		reader:     mockCSVReader,
 		fileReader: mockFileReader,
 		logger:     logger,
+		tracer:     types.NewTracerStub(),
 		urls:       urlChan,
 	}
 
@@ -40,8 +45,12 @@ func TestFetchURLs(t *testing.T) {
 
 	// Collect URLs from the channel
 	var urls []string
-	for url := range urlChan {
-		urls = append(urls, url)
+	var rows []int
+	ids := map[string]bool{}
+	for job := range urlChan {
+		urls = append(urls, job.URL)
+		rows = append(rows, job.Row)
+		ids[job.ID] = true
 	}
 
 	expectedURLs := []string{
@@ -51,6 +60,9 @@ func TestFetchURLs(t *testing.T) {
 	}
 
 	assert.Equal(t, expectedURLs, urls)
+	assert.Equal(t, []int{1, 2, 3}, rows)
+	assert.Len(t, ids, 3)
+	assert.NotContains(t, ids, "")
 }
 
 func TestFetchURLs_ErrorReadingHeader(t *testing.T) {
@@ -60,12 +72,13 @@ func TestFetchURLs_ErrorReadingHeader(t *testing.T) {
 	mockCSVReader := mocks.NewMockCSVReadable(ctrl)
 	mockFileReader := mocks.NewMockFileReadable(ctrl)
 	logger := types.NewLoggerStub()
-	urlChan := make(chan string, 10)
+	urlChan := make(chan *types.Job, 10)
 
 	csv := &csvReader{
 		reader:     mockCSVReader,
 		fileReader: mockFileReader,
 		logger:     logger,
+		tracer:     types.NewTracerStub(),
 		urls:       urlChan,
 	}
 
@@ -88,6 +101,7 @@ func TestClose(t *testing.T) {
 	csv := &csvReader{
 		fileReader: mockFileReader,
 		logger:     logger,
+		tracer:     types.NewTracerStub(),
 	}
 
 	mockFileReader.EXPECT().Close().Return(nil)
@@ -106,6 +120,7 @@ func TestClose_Error(t *testing.T) {
 	csv := &csvReader{
 		fileReader: mockFileReader,
 		logger:     logger,
+		tracer:     types.NewTracerStub(),
 	}
 
 	mockFileReader.EXPECT().Close().Return(errors.New("error closing file"))
@@ -114,3 +129,66 @@ func TestClose_Error(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "error closing file", err.Error())
 }
+
+func TestFetchURLs_Fields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCSVReader := mocks.NewMockCSVReadable(ctrl)
+	urlChan := make(chan *types.Job, 10)
+
+	csv := &csvReader{
+		reader: mockCSVReader,
+		logger: types.NewLoggerStub(),
+		tracer: types.NewTracerStub(),
+		urls:   urlChan,
+	}
+
+	gomock.InOrder(
+		mockCSVReader.EXPECT().Read().Return([]string{"Urls", " Existing_File ", ""}, nil),
+		mockCSVReader.EXPECT().Read().Return([]string{"www.example.com", " skip", "ignored"}, nil),
+		mockCSVReader.EXPECT().Read().Return(nil, io.EOF),
+	)
+
+	go csv.fetchURLs()
+
+	var jobs []*types.Job
+	for job := range urlChan {
+		jobs = append(jobs, job)
+	}
+
+	assert.Len(t, jobs, 1)
+	assert.Equal(t, map[string]string{"existing_file": "skip"}, jobs[0].Fields)
+	assert.Nil(t, fields([]string{"Urls"}, []string{"www.example.com"}))
+}
+
+func TestCountRecords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.csv")
+	content := "Urls,note\nhttps://example.com/a,\"spans\ntwo lines\"\nhttps://example.com/b,\n"
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	total, err := countRecords(path)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), total)
+
+	assert.NoError(t, os.WriteFile(path, nil, 0644))
+	total, err = countRecords(path)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), total)
+
+	_, err = countRecords(filepath.Join(t.TempDir(), "missing.csv"))
+	assert.Error(t, err)
+}
+
+func TestNewCSVReader_TotalURLs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.csv")
+	assert.NoError(t, os.WriteFile(path, []byte("Urls\nhttps://example.com/a\nhttps://example.com/b\n"), 0644))
+
+	urls := make(chan *types.Job, 2)
+	csv, err := NewCSVReader(config.ReadConfig{FilePath: path}, types.NewLoggerStub(), types.NewTracerStub(), urls)
+	assert.NoError(t, err)
+	defer csv.Close()
+
+	assert.Eventually(t, func() bool { return csv.GetTotalURLs() == 2 }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return csv.GetReadURLs() == 2 }, time.Second, time.Millisecond)
+}
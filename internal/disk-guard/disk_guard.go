@@ -0,0 +1,260 @@
+package diskguard
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+const (
+	DefaultMaxPause      = 10 * time.Minute
+	DefaultCheckInterval = time.Second
+)
+
+var (
+	ErrLowDiskSpace   = failure.New(failure.ClassDiskSpace, "free disk space stayed below the low-water mark")
+	ErrQuotaExceeded  = failure.New(failure.ClassQuotaExceeded, "output quota exceeded")
+	ErrNoSpaceForFile = failure.New(failure.ClassDiskSpace, "file would take free disk space below the low-water mark")
+)
+
+type diskGuard struct {
+	config    config.SpaceConfig
+	dir       string
+	logger    types.Logger
+	freeSpace func(dir string) (int64, error)
+	interval  time.Duration
+	maxPause  time.Duration
+	stop      chan struct{}
+	closeOnce sync.Once
+
+	mu       sync.Mutex
+	paused   bool
+	pausedAt time.Time
+	resumed  chan struct{}
+	stopErr  error
+
+	// stat variables
+	stats struct {
+		pauses    atomic.Int32
+		pausedFor atomic.Int64
+		freeBytes atomic.Int64
+		written   atomic.Int64
+	}
+}
+
+// NewDiskGuard checks the free space on the output directory and, with a low-water mark configured,
+// keeps checking it in the background to pause and resume downloads.
+func NewDiskGuard(ctx context.Context, config config.WriteConfig, logger types.Logger) *diskGuard {
+	maxPause := time.Duration(config.Space.MaxPauseSeconds) * time.Second
+	if maxPause == 0 {
+		maxPause = DefaultMaxPause
+	}
+
+	guard := &diskGuard{
+		config:    config.Space,
+		dir:       config.WriteDir,
+		logger:    logger,
+		freeSpace: freeSpace,
+		interval:  DefaultCheckInterval,
+		maxPause:  maxPause,
+		stop:      make(chan struct{}),
+		resumed:   make(chan struct{}),
+	}
+
+	if guard.config.MinFreeBytes > 0 {
+		if _, err := guard.freeSpace(guard.dir); err != nil {
+			guard.logger.Warnf("Ignoring minimum free space: %s", err)
+			guard.config.MinFreeBytes = 0
+		} else {
+			guard.check()
+			go guard.monitor(ctx)
+		}
+	}
+
+	guard.logger.Infof("Disk guard started")
+	return guard
+}
+
+// monitor checks the free space periodically until the guard is closed.
+func (g *diskGuard) monitor(ctx context.Context) {
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			g.check()
+		case <-g.stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// check pauses downloads when free space drops below the low-water mark, resumes them once
+// there is enough again and stops the run when the pause lasts too long.
+func (g *diskGuard) check() {
+	free, err := g.freeSpace(g.dir)
+	if err != nil {
+		g.logger.Errorf("Failed to check free space on %s: %s", g.dir, err)
+		return
+	}
+	g.stats.freeBytes.Store(free)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch {
+	case free < g.config.MinFreeBytes && !g.paused:
+		g.pauseLocked(free)
+	case free >= g.config.MinFreeBytes && g.paused:
+		g.resumeLocked(free)
+	case g.paused && time.Since(g.pausedAt) > g.maxPause:
+		g.stopLocked(fmt.Errorf("%w: %d bytes free after waiting %s", ErrLowDiskSpace, free, g.maxPause))
+	}
+}
+
+func (g *diskGuard) pauseLocked(free int64) {
+	g.paused = true
+	g.pausedAt = time.Now()
+	g.stats.pauses.Add(1)
+	g.logger.Warnf("Pausing downloads, %d bytes free on %s is below %d", free, g.dir, g.config.MinFreeBytes)
+}
+
+func (g *diskGuard) resumeLocked(free int64) {
+	g.paused = false
+	g.stats.pausedFor.Add(int64(time.Since(g.pausedAt)))
+	close(g.resumed)
+	g.resumed = make(chan struct{})
+	g.logger.Infof("Resuming downloads, %d bytes free on %s", free, g.dir)
+}
+
+// stopLocked records the first stop reason and wakes everyone waiting for space.
+func (g *diskGuard) stopLocked(err error) {
+	if g.stopErr != nil {
+		return
+	}
+	g.stopErr = err
+	close(g.resumed)
+	g.resumed = make(chan struct{})
+	g.logger.Errorf("Stopping run: %s", err)
+}
+
+// Wait blocks while downloads are paused and returns the stop reason once the run has to stop.
+func (g *diskGuard) Wait(ctx context.Context) error {
+	for {
+		g.mu.Lock()
+		err, paused, resumed := g.stopErr, g.paused, g.resumed
+		g.mu.Unlock()
+
+		if err != nil || !paused {
+			return err
+		}
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Reserve accounts for size bytes about to be written. It fails and stops the run when they exceed
+// the quota, and waits like Wait while free space is below the low-water mark.
+func (g *diskGuard) Reserve(size int64) error {
+	for {
+		g.mu.Lock()
+		if g.stopErr != nil {
+			g.mu.Unlock()
+			return g.stopErr
+		}
+		if quota := g.config.MaxTotalBytes; quota > 0 && g.stats.written.Load()+size > quota {
+			g.stopLocked(fmt.Errorf("%w: %d of %d bytes written", ErrQuotaExceeded, g.stats.written.Load(), quota))
+			g.mu.Unlock()
+			return g.stopErr
+		}
+		ok, err := g.hasSpaceLocked(size)
+		if err != nil || ok {
+			if ok {
+				g.stats.written.Add(size)
+			}
+			g.mu.Unlock()
+			return err
+		}
+		resumed := g.resumed
+		g.mu.Unlock()
+
+		select {
+		case <-resumed:
+		case <-g.stop:
+			return ErrLowDiskSpace
+		}
+	}
+}
+
+// hasSpaceLocked reports whether size bytes fit above the low-water mark. It pauses downloads when
+// free space already is below the mark, and fails when only this write would not fit.
+func (g *diskGuard) hasSpaceLocked(size int64) (bool, error) {
+	if g.config.MinFreeBytes == 0 {
+		return true, nil
+	}
+	if g.paused {
+		return false, nil
+	}
+
+	free, err := g.freeSpace(g.dir)
+	if err != nil {
+		// Without a way to tell, writes go ahead as they would without the guard.
+		return true, nil
+	}
+	g.stats.freeBytes.Store(free)
+
+	switch {
+	case free < g.config.MinFreeBytes:
+		g.pauseLocked(free)
+		return false, nil
+	case free-size < g.config.MinFreeBytes:
+		return false, fmt.Errorf("%w: %d bytes do not fit into %d bytes free", ErrNoSpaceForFile, size, free)
+	}
+	return true, nil
+}
+
+// Release gives back size bytes reserved for a write that failed or was skipped, so they do not count
+// against the quota.
+func (g *diskGuard) Release(size int64) {
+	g.stats.written.Add(-size)
+}
+
+// StopReason returns why the run was stopped, or nil.
+func (g *diskGuard) StopReason() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stopErr
+}
+
+// Close stops the background checks.
+func (g *diskGuard) Close() {
+	g.closeOnce.Do(func() {
+		close(g.stop)
+	})
+}
+
+func (g *diskGuard) GetStats() *types.GuardStats {
+	g.mu.Lock()
+	paused := g.paused
+	g.mu.Unlock()
+
+	return &types.GuardStats{
+		Paused:       paused,
+		Pauses:       g.stats.pauses.Load(),
+		PausedSec:    time.Duration(g.stats.pausedFor.Load()).Seconds(),
+		FreeBytes:    g.stats.freeBytes.Load(),
+		WrittenBytes: g.stats.written.Load(),
+	}
+}
@@ -0,0 +1,134 @@
+package diskguard
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestGuard returns a guard reading free space from free instead of the filesystem.
+func newTestGuard(space config.SpaceConfig, free *atomic.Int64) *diskGuard {
+	return &diskGuard{
+		config: space,
+		logger: types.NewLoggerStub(),
+		freeSpace: func(dir string) (int64, error) {
+			return free.Load(), nil
+		},
+		interval: time.Millisecond,
+		maxPause: time.Hour,
+		stop:     make(chan struct{}),
+		resumed:  make(chan struct{}),
+	}
+}
+
+func TestNewDiskGuard(t *testing.T) {
+	cfg := config.WriteConfig{WriteDir: t.TempDir(), Space: config.SpaceConfig{MinFreeBytes: 1}}
+
+	guard := NewDiskGuard(context.Background(), cfg, types.NewLoggerStub())
+	defer guard.Close()
+
+	assert.Equal(t, DefaultMaxPause, guard.maxPause)
+	assert.Positive(t, guard.stats.freeBytes.Load())
+	assert.NoError(t, guard.Wait(context.Background()))
+	assert.NoError(t, guard.Reserve(10))
+}
+
+func TestReserve_Quota(t *testing.T) {
+	var free atomic.Int64
+	guard := newTestGuard(config.SpaceConfig{MaxTotalBytes: 100}, &free)
+
+	assert.NoError(t, guard.Reserve(60))
+	assert.NoError(t, guard.Reserve(40))
+
+	err := guard.Reserve(1)
+	assert.ErrorIs(t, err, ErrQuotaExceeded)
+	assert.ErrorIs(t, guard.StopReason(), ErrQuotaExceeded)
+	assert.ErrorIs(t, guard.Wait(context.Background()), ErrQuotaExceeded)
+	assert.Equal(t, int64(100), guard.stats.written.Load())
+}
+
+func TestRelease(t *testing.T) {
+	var free atomic.Int64
+	guard := newTestGuard(config.SpaceConfig{MaxTotalBytes: 100}, &free)
+
+	// Bytes of a failed write are given back and fit into the quota again.
+	assert.NoError(t, guard.Reserve(60))
+	guard.Release(60)
+	assert.NoError(t, guard.Reserve(100))
+	assert.Equal(t, int64(100), guard.GetStats().WrittenBytes)
+	assert.NoError(t, guard.StopReason())
+}
+
+func TestReserve_FileTooLarge(t *testing.T) {
+	var free atomic.Int64
+	free.Store(150)
+	guard := newTestGuard(config.SpaceConfig{MinFreeBytes: 100}, &free)
+
+	assert.NoError(t, guard.Reserve(50))
+	assert.ErrorIs(t, guard.Reserve(51), ErrNoSpaceForFile)
+	assert.False(t, guard.paused)
+	assert.NoError(t, guard.StopReason())
+}
+
+func TestPauseAndResume(t *testing.T) {
+	var free atomic.Int64
+	free.Store(50)
+	guard := newTestGuard(config.SpaceConfig{MinFreeBytes: 100}, &free)
+	defer guard.Close()
+
+	guard.check()
+	assert.True(t, guard.paused)
+
+	go guard.monitor(context.Background())
+
+	waited := make(chan error, 2)
+	go func() { waited <- guard.Wait(context.Background()) }()
+	go func() { waited <- guard.Reserve(10) }()
+
+	select {
+	case <-waited:
+		t.Fatal("downloads and writes must wait while space is low")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	free.Store(200)
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-waited:
+			assert.NoError(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("downloads and writes must resume once space is free")
+		}
+	}
+	assert.Equal(t, int32(1), guard.stats.pauses.Load())
+}
+
+func TestPauseTimeout(t *testing.T) {
+	var free atomic.Int64
+	guard := newTestGuard(config.SpaceConfig{MinFreeBytes: 100}, &free)
+	guard.maxPause = 10 * time.Millisecond
+	defer guard.Close()
+
+	guard.check()
+	go guard.monitor(context.Background())
+
+	err := guard.Wait(context.Background())
+	assert.ErrorIs(t, err, ErrLowDiskSpace)
+	assert.ErrorIs(t, guard.Reserve(1), ErrLowDiskSpace)
+}
+
+func TestWait_ContextDone(t *testing.T) {
+	var free atomic.Int64
+	guard := newTestGuard(config.SpaceConfig{MinFreeBytes: 100}, &free)
+	guard.check()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.True(t, errors.Is(guard.Wait(ctx), context.Canceled))
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package diskguard
+
+import "errors"
+
+// freeSpace is not implemented on this platform, so only the quota is enforced.
+func freeSpace(dir string) (int64, error) {
+	return 0, errors.New("free space check not supported on this platform")
+}
@@ -0,0 +1,78 @@
+package downloader
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+)
+
+// StatusError is returned for a response with any other status than 200 OK.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return e.Status
+}
+
+// classify returns the class of a failed download. Errors of the downloader carry their class
+// already; network and TLS errors are told apart by their type.
+func classify(err error) string {
+	var (
+		classified *failure.Error
+		status     *StatusError
+		dnsErr     *net.DNSError
+		netErr     net.Error
+	)
+	switch {
+	case errors.As(err, &classified):
+		return classified.Class
+	case errors.As(err, &status):
+		switch {
+		case status.StatusCode >= 400 && status.StatusCode < 500:
+			return failure.ClassHTTP4xx
+		case status.StatusCode >= 500 && status.StatusCode < 600:
+			return failure.ClassHTTP5xx
+		}
+		return failure.ClassHTTPStatus
+	case errors.As(err, &dnsErr):
+		return failure.ClassDNS
+	case isTLSError(err):
+		return failure.ClassTLS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return failure.ClassConnectionRefused
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return failure.ClassTimeout
+	case errors.Is(err, context.Canceled):
+		return failure.ClassCanceled
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNABORTED), errors.Is(err, syscall.EPIPE),
+		errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH), errors.As(err, new(*net.OpError)):
+		return failure.ClassConnection
+	}
+	return failure.ClassOther
+}
+
+// isTLSError reports whether err comes from the TLS handshake, on either side.
+func isTLSError(err error) bool {
+	var (
+		recordErr      tls.RecordHeaderError
+		alertErr       tls.AlertError
+		verifyErr      *tls.CertificateVerificationError
+		unknownAuthErr x509.UnknownAuthorityError
+		hostnameErr    x509.HostnameError
+		invalidCertErr x509.CertificateInvalidError
+		opErr          *net.OpError
+	)
+	return errors.As(err, &recordErr) || errors.As(err, &alertErr) || errors.As(err, &verifyErr) ||
+		errors.As(err, &unknownAuthErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidCertErr) ||
+		// Alerts sent by the server, such as a failed handshake, are only told apart by the operation.
+		errors.As(err, &opErr) && opErr.Op == "remote error"
+}
@@ -0,0 +1,82 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	dnsErr := &net.DNSError{Err: "no such host", Name: "nowhere.invalid", IsNotFound: true}
+
+	assert.Equal(t, failure.ClassDNS, classify(fmt.Errorf("failed to fetch: %w", dnsErr)))
+	assert.Equal(t, failure.ClassTimeout, classify(fmt.Errorf("failed to fetch: %w", context.DeadlineExceeded)))
+	assert.Equal(t, failure.ClassCanceled, classify(context.Canceled))
+	assert.Equal(t, failure.ClassHTTP4xx, classify(&StatusError{StatusCode: 404, Status: "404 Not Found"}))
+	assert.Equal(t, failure.ClassHTTP5xx, classify(&StatusError{StatusCode: 503, Status: "503 Service Unavailable"}))
+	assert.Equal(t, failure.ClassHTTPStatus, classify(&StatusError{StatusCode: 204, Status: "204 No Content"}))
+	assert.Equal(t, failure.ClassTooLarge, classify(fmt.Errorf("rejected: %w", ErrTooLarge)))
+	assert.Equal(t, failure.ClassOther, classify(errors.New("unknown")))
+}
+
+func TestFetchContent_Classes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+	tlsServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer tlsServer.Close()
+
+	// A listener closed right away leaves a port nothing listens on.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	refused := "http://" + listener.Addr().String()
+	listener.Close()
+
+	d := &downloader{
+		logger: types.NewLoggerStub(),
+		client: &http.Client{Timeout: 10 * time.Second},
+		cache:  types.NewCacheStub(),
+	}
+
+	for url, class := range map[string]string{
+		server.URL + "/missing": failure.ClassHTTP4xx,
+		server.URL + "/broken":  failure.ClassHTTP5xx,
+		tlsServer.URL:           failure.ClassTLS,
+		refused:                 failure.ClassConnectionRefused,
+	} {
+		_, err := d.fetchContent(url)
+		assert.Error(t, err, url)
+		assert.Equal(t, class, failure.ClassOf(err), url)
+	}
+
+	// Only the slow request gets a client timing out before the server answers.
+	d.client = &http.Client{Timeout: 50 * time.Millisecond}
+	_, err = d.fetchContent(server.URL + "/slow")
+	assert.Error(t, err)
+	assert.Equal(t, failure.ClassTimeout, failure.ClassOf(err))
+
+	assert.Equal(t, map[string]int32{
+		failure.ClassHTTP4xx:           1,
+		failure.ClassHTTP5xx:           1,
+		failure.ClassTimeout:           1,
+		failure.ClassTLS:               1,
+		failure.ClassConnectionRefused: 1,
+	}, d.GetStats().FailedReasons)
+}
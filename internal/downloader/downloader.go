@@ -2,87 +2,213 @@ package downloader
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
+	"net/http/httptrace"
 	"sync"
 	"sync/atomic"
+	"time"
 
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/latency"
 	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	ParallelDownload = 50
-	HTTPPrefix       = "http"
-	HTTPSPrefix      = "https://"
 )
 
 type downloader struct {
-	ctx    context.Context
-	logger types.Logger
-	reader types.Readable
-	writer types.Writable
-	finish chan struct{}
-	urls   chan string
-	lock   chan struct{}
+	ctx      context.Context
+	config   config.DownloadConfig
+	logger   types.Logger
+	client   *http.Client
+	reader   types.Readable
+	writer   types.Writable
+	reporter types.Reportable
+	cache    types.Cacheable
+	guard    types.Guardable
+	observer types.Observable
+	tracer   types.Traceable
+	finish   chan struct{}
+	urls     chan *types.Job
+	lock     chan struct{}
+	hosts    hostStats
+	failures failure.Counts
 
 	stats struct {
 		activeDownloads    atomic.Int32
 		downloadSuccessful atomic.Int32
 		downloadFailed     atomic.Int32
+		redirected         atomic.Int32
+		redirectBlocked    atomic.Int32
+		destinationBlocked atomic.Int32
+		unchanged          atomic.Int32
+		tooLarge           atomic.Int32
+		quotaExceeded      atomic.Int32
+		contentTypeDenied  atomic.Int32
+		bytesDownloaded    atomic.Int64
 	}
 }
 
 // NewDownloader initializes a new downloader instance and starts processing and monitoring routines.
-func NewDownloader(ctx context.Context, logger types.Logger, reader types.Readable, writer types.Writable) *downloader {
+func NewDownloader(ctx context.Context, config config.DownloadConfig, logger types.Logger, reader types.Readable, writer types.Writable, reporter types.Reportable, cache types.Cacheable, guard types.Guardable, observer types.Observable, tracer types.Traceable) (*downloader, error) {
+	client, err := newHTTPClient(config, logger)
+	if err != nil {
+		return nil, fmt.Errorf("caught err while building http client: %w", err)
+	}
+
 	down := &downloader{
-		ctx:    ctx,
-		logger: logger,
-		reader: reader,
-		writer: writer,
-		finish: make(chan struct{}),
-		urls:   make(chan string),
-		lock:   make(chan struct{}, ParallelDownload),
+		ctx:      ctx,
+		config:   config,
+		logger:   logger,
+		client:   client,
+		reader:   reader,
+		writer:   writer,
+		reporter: reporter,
+		cache:    cache,
+		guard:    guard,
+		observer: observer,
+		tracer:   tracer,
+		finish:   make(chan struct{}),
+		urls:     make(chan *types.Job),
+		lock:     make(chan struct{}, ParallelDownload),
 	}
 
 	go down.startProcessing()
 
 	down.logger.Infof("Downloader started")
-	return down
+	return down, nil
 }
 
-// formatURL ensures the URL has the correct prefix (http or https).
-func (d *downloader) formatURL(url string) string {
-	if !strings.HasPrefix(url, HTTPPrefix) {
-		url = fmt.Sprintf("%s%s", HTTPSPrefix, url)
+// fetchContent retrieves the content from the given URL and counts the reason of failed downloads.
+func (d *downloader) fetchContent(url string) (*types.Download, error) {
+	download, err := d.fetch(url)
+	if err != nil {
+		err = failure.Classify(classify(err), err)
+		d.countFailure(err)
 	}
-	return url
+	return download, err
 }
 
-// fetchContent retrieves the content from the given URL.
-func (d *downloader) fetchContent(url string) ([]byte, error) {
-	resp, err := http.Get(url)
+// fetch retrieves the content from the given URL, following redirects allowed by the policy.
+// Cached validators are sent along, and a 304 response yields a download marked NotModified.
+// The returned download carries the redirect chain even when an error is returned.
+func (d *downloader) fetch(url string) (*types.Download, error) {
+	download := &types.Download{URL: url, StartedAt: time.Now().UTC()}
+	trace := newPhaseTrace()
+	defer func() {
+		download.FinishedAt = time.Now().UTC()
+		download.Phases = trace.get()
+	}()
+
+	limits := d.config.Limits
+	if limits.MaxTotalSize > 0 && d.stats.bytesDownloaded.Load() >= limits.MaxTotalSize {
+		return download, fmt.Errorf("skipped URL %s: %w", url, ErrRunQuotaExceeded)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return download, fmt.Errorf("failed to build request for URL %s: %w", url, err)
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+
+	cached, isCached := d.cache.Get(url)
+	if isCached {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := d.client.Do(req)
+	if resp != nil {
+		download.FinalURL = resp.Request.URL.String()
+		download.Redirects = redirectChain(resp)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch URL %s: %w", url, err)
+		return download, fmt.Errorf("failed to fetch URL %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
+	if len(download.Redirects) > 0 {
+		d.stats.redirected.Add(1)
+		d.logger.Debugf("URL %s redirected to %s", url, download.FinalURL)
+	}
+
+	download.StatusCode = resp.StatusCode
+	download.Header = resp.Header
+
+	if resp.StatusCode == http.StatusNotModified && isCached {
+		download.NotModified = true
+		download.SHA256 = cached.SHA256
+		return download, nil
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bad response from URL %s: %s", url, resp.Status)
+		return download, fmt.Errorf("bad response from URL %s: %w", url, &StatusError{StatusCode: resp.StatusCode, Status: resp.Status})
+	}
+
+	if err := checkResponse(limits, resp, d.stats.bytesDownloaded.Load()); err != nil {
+		return download, fmt.Errorf("rejected URL %s: %w", url, err)
+	}
+
+	content, err := io.ReadAll(newLimitedReader(resp.Body, limits, &d.stats.bytesDownloaded))
+	if err != nil {
+		return download, fmt.Errorf("failed to read body of URL %s: %w", url, err)
 	}
+	trace.bodyRead()
+	download.Content = content
+
+	digest := sha256.Sum256(content)
+	download.SHA256 = hex.EncodeToString(digest[:])
 
-	return io.ReadAll(resp.Body)
+	return download, nil
 }
 
-// download formats the URL and fetches its content.
-func (d *downloader) download(url string) ([]byte, error) {
-	formattedURL := d.formatURL(url)
-	return d.fetchContent(formattedURL)
+// countFailure counts a failed download by its class, and increments the stat matching the class.
+func (d *downloader) countFailure(err error) {
+	switch d.failures.Add(err) {
+	case failure.ClassRedirectBlocked:
+		d.stats.redirectBlocked.Add(1)
+	case failure.ClassDestinationBlocked:
+		d.stats.destinationBlocked.Add(1)
+	case failure.ClassTooLarge:
+		d.stats.tooLarge.Add(1)
+	case failure.ClassQuotaExceeded:
+		d.stats.quotaExceeded.Add(1)
+	case failure.ClassContentType:
+		d.stats.contentTypeDenied.Add(1)
+	}
+}
+
+// updateCache stores the validators of the download, if the server sent any. It is called once the
+// download is written, as a cached URL answered with 304 is not written again.
+func (d *downloader) updateCache(download *types.Download) {
+	etag := download.Header.Get("ETag")
+	lastModified := download.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return
+	}
+
+	d.cache.Put(download.URL, types.CacheEntry{
+		ETag:         etag,
+		LastModified: lastModified,
+		SHA256:       download.SHA256,
+		UpdatedAt:    time.Now().UTC(),
+	})
 }
 
 // downloadAndPush downloads the content from the URL and pushes it to the writer.
-func (d *downloader) downloadAndPush(url string, wg *sync.WaitGroup) {
+func (d *downloader) downloadAndPush(job *types.Job, wg *sync.WaitGroup) {
 	defer func() {
 		<-d.lock
 		wg.Done()
@@ -90,16 +216,61 @@ func (d *downloader) downloadAndPush(url string, wg *sync.WaitGroup) {
 	}()
 
 	d.stats.activeDownloads.Add(1) // Increment the counter
+	logger := d.logger.With(job.LogFields()...)
 
-	content, err := d.download(url)
+	ctx, _ := d.tracer.Start(job.Context(), "download", trace.WithAttributes(
+		semconv.URLFull(job.URL), semconv.ServerAddress(latency.HostOf(job.URL)), semconv.HTTPRequestMethodGet))
+	start := time.Now()
+	download, err := d.fetchContent(job.URL)
+	elapsed := time.Since(start)
+	d.observer.ObserveDownload(elapsed)
+	d.hosts.record(job.URL, download, elapsed, err)
+	for phase, duration := range download.Phases {
+		d.observer.ObservePhase(phase, duration)
+	}
+	endDownloadSpan(ctx, download, err)
 	if err != nil {
-		d.logger.Debugf("Error downloading URL: %s - %s", url, err)
+		logger.Debugf("Error downloading URL: %s", err)
 		d.stats.downloadFailed.Add(1)
+		d.report(job, download, err)
+		types.EndJob(job.Context(), types.ReportStatusFailed, err)
+		return
+	}
+
+	if download.NotModified {
+		logger.Debugf("URL unchanged since last download")
+		d.stats.unchanged.Add(1)
+		d.report(job, download, nil)
+		types.EndJob(job.Context(), types.ReportStatusUnchanged, nil)
 		return
 	}
 
 	d.stats.downloadSuccessful.Add(1)
-	d.writer.PushForWrite(content)
+	logger.Debugf("Downloaded %d bytes", len(download.Content))
+	download.JobID = job.ID
+	download.Row = job.Row
+	download.Fields = job.Fields
+	download.Ctx = job.Ctx
+	download.OnWritten = func() { d.updateCache(download) }
+	d.writer.PushForWrite(download)
+}
+
+// report records a download in the report that failed or was unchanged. The writer records the
+// downloads it is pushed, once they are written.
+func (d *downloader) report(job *types.Job, download *types.Download, err error) {
+	entry := types.ReportEntry{
+		Row:       job.Row,
+		URL:       download.URL,
+		FinalURL:  download.FinalURL,
+		Redirects: download.Redirects,
+		Status:    types.ReportStatusUnchanged,
+	}
+	if err != nil {
+		entry.Status = types.ReportStatusFailed
+		entry.Error = err.Error()
+		entry.ErrorClass = failure.ClassOf(err)
+	}
+	d.reporter.Record(entry)
 }
 
 // downloadWorker processes URLs from the channel and starts downloadAndPush for each URL.
@@ -109,22 +280,43 @@ func (d *downloader) downloadWorker(wg *sync.WaitGroup) {
 	downloadWG := sync.WaitGroup{}
 	defer downloadWG.Wait()
 
+	// stopErr is set once the run has to stop. The remaining jobs are still taken, so the stages
+	// sending them are not blocked, and reported as not downloaded.
+	var stopErr error
 	for {
 		select {
 		case <-d.ctx.Done():
 			return
-		case url, ok := <-d.urls:
+		case job, ok := <-d.urls:
 			if !ok {
 				return
 			}
+			if stopErr != nil {
+				d.skip(job, stopErr)
+				continue
+			}
+
+			// Downloads pause while the output is short of space and end once the run has to stop.
+			if err := d.guard.Wait(d.ctx); err != nil {
+				d.logger.Errorf("Stopping downloads: %s", err)
+				stopErr = err
+				d.skip(job, err)
+				continue
+			}
 
 			d.lock <- struct{}{}
 			downloadWG.Add(1)
-			go d.downloadAndPush(url, &downloadWG)
+			go d.downloadAndPush(job, &downloadWG)
 		}
 	}
 }
 
+// skip reports a job that is not downloaded because the run has to stop, and ends its span.
+func (d *downloader) skip(job *types.Job, err error) {
+	d.report(job, &types.Download{URL: job.URL}, err)
+	types.EndJob(job.Context(), types.ReportStatusFailed, err)
+}
+
 // startProcessing starts the download worker and waits for it to finish.
 func (d *downloader) startProcessing() {
 	defer d.finishProcessing()
@@ -146,20 +338,34 @@ func (d *downloader) GetFinishChan() chan struct{} {
 }
 
 // GetURLsChan returns the URLs channel.
-func (d *downloader) GetURLsChan() chan string {
+func (d *downloader) GetURLsChan() chan *types.Job {
 	return d.urls
 }
 
-func (d *downloader) GetStats() any {
-	type stats struct {
-		ActiveDownloads    int32 `json:"active_downloads"`
-		DownloadSuccessful int32 `json:"download_successful"`
-		DownloadFailed     int32 `json:"download_failed"`
-	}
+// GetHostStats returns the download results per host.
+func (d *downloader) GetHostStats() map[string]types.HostStats {
+	return d.hosts.get()
+}
+
+// GetRecentErrors returns the latest download failures, oldest first.
+func (d *downloader) GetRecentErrors() []types.RecentError {
+	return d.hosts.recentErrors()
+}
 
-	return stats{
+func (d *downloader) GetStats() types.DownloaderStats {
+	return types.DownloaderStats{
 		ActiveDownloads:    d.stats.activeDownloads.Load(),
 		DownloadSuccessful: d.stats.downloadSuccessful.Load(),
 		DownloadFailed:     d.stats.downloadFailed.Load(),
+		Redirected:         d.stats.redirected.Load(),
+		RedirectBlocked:    d.stats.redirectBlocked.Load(),
+		DestinationBlocked: d.stats.destinationBlocked.Load(),
+		Unchanged:          d.stats.unchanged.Load(),
+		TooLarge:           d.stats.tooLarge.Load(),
+		QuotaExceeded:      d.stats.quotaExceeded.Load(),
+		ContentTypeDenied:  d.stats.contentTypeDenied.Load(),
+		BytesDownloaded:    d.stats.bytesDownloaded.Load(),
+		FailedReasons:      d.failures.Get(),
+		Phases:             d.hosts.phases.Stats(),
 	}
 }
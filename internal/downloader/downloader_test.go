@@ -1,43 +1,46 @@
 package downloader
 
 import (
+	"bytes"
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"sync"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
 	"github.com/puruabhi/jfrog/home-assignment/internal/types"
 	typeMocks "github.com/puruabhi/jfrog/home-assignment/internal/types/mocks"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestFormatURL(t *testing.T) {
-	logger := types.NewLoggerStub()
-	d := &downloader{
-		logger: logger,
-	}
+// downloadMatcher matches a download of url with the given content.
+type downloadMatcher struct {
+	url     string
+	content []byte
+}
 
-	tests := []struct {
-		input    string
-		expected string
-	}{
-		{"example.com", "https://example.com"},
-		{"http://example.com", "http://example.com"},
-		{"https://example.com", "https://example.com"},
-	}
+func downloadOf(url string, content []byte) gomock.Matcher {
+	return downloadMatcher{url: url, content: content}
+}
 
-	for _, test := range tests {
-		result := d.formatURL(test.input)
-		assert.Equal(t, test.expected, result)
-	}
+func (m downloadMatcher) Matches(x any) bool {
+	download, ok := x.(*types.Download)
+	return ok && download.URL == m.url && bytes.Equal(download.Content, m.content)
+}
+
+func (m downloadMatcher) String() string {
+	return fmt.Sprintf("download of %s with content %q", m.url, m.content)
 }
 
 func TestFetchContent(t *testing.T) {
 	logger := types.NewLoggerStub()
 	d := &downloader{
 		logger: logger,
+		client: http.DefaultClient,
+		cache:  types.NewCacheStub(),
 	}
 
 	serverMockResponse := "test content"
@@ -51,15 +54,19 @@ func TestFetchContent(t *testing.T) {
 	url := server.URL
 	expectedContent := serverMockResponse
 
-	content, err := d.fetchContent(url)
+	download, err := d.fetchContent(url)
 	assert.NoError(t, err)
-	assert.Equal(t, expectedContent, string(content))
+	assert.Equal(t, expectedContent, string(download.Content))
+	assert.Equal(t, url, download.FinalURL)
+	assert.Empty(t, download.Redirects)
 }
 
 func TestFetchContent_Error(t *testing.T) {
 	logger := types.NewLoggerStub()
 	d := &downloader{
 		logger: logger,
+		client: http.DefaultClient,
+		cache:  types.NewCacheStub(),
 	}
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -82,9 +89,14 @@ func TestDownloadAndPush(t *testing.T) {
 	mockWriter := typeMocks.NewMockWritable(ctrl)
 	logger := types.NewLoggerStub()
 	d := &downloader{
-		logger: logger,
-		writer: mockWriter,
-		lock:   make(chan struct{}, 1),
+		logger:   logger,
+		client:   http.DefaultClient,
+		writer:   mockWriter,
+		reporter: types.NewReporterStub(),
+		cache:    types.NewCacheStub(),
+		observer: types.NewObserverStub(),
+		tracer:   types.NewTracerStub(),
+		lock:     make(chan struct{}, 1),
 	}
 
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -96,13 +108,13 @@ func TestDownloadAndPush(t *testing.T) {
 	url := server.URL
 	content := []byte(serverMockResponse)
 
-	mockWriter.EXPECT().PushForWrite(content).Times(1)
+	mockWriter.EXPECT().PushForWrite(downloadOf(url, content)).Times(1)
 
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
 	d.lock <- struct{}{}
 	go func() {
-		d.downloadAndPush(url, wg)
+		d.downloadAndPush(&types.Job{Row: 1, URL: url}, wg)
 	}()
 
 	wg.Wait()
@@ -119,12 +131,18 @@ func TestDownloadWorker(t *testing.T) {
 	defer cancel()
 
 	d := &downloader{
-		ctx:    ctx,
-		logger: logger,
-		reader: mockReader,
-		writer: mockWriter,
-		urls:   make(chan string, 1),
-		lock:   make(chan struct{}, 1),
+		ctx:      ctx,
+		logger:   logger,
+		client:   http.DefaultClient,
+		reader:   mockReader,
+		writer:   mockWriter,
+		reporter: types.NewReporterStub(),
+		cache:    types.NewCacheStub(),
+		guard:    types.NewGuardStub(),
+		observer: types.NewObserverStub(),
+		tracer:   types.NewTracerStub(),
+		urls:     make(chan *types.Job, 1),
+		lock:     make(chan struct{}, 1),
 	}
 
 	serverMockResponse := "test content"
@@ -138,15 +156,202 @@ func TestDownloadWorker(t *testing.T) {
 	url := server.URL
 	content := []byte(serverMockResponse)
 
-	mockWriter.EXPECT().PushForWrite(content).Times(1)
+	mockWriter.EXPECT().PushForWrite(downloadOf(url, content)).Times(1)
 
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
 
 	go d.downloadWorker(wg)
 
-	d.urls <- url
+	d.urls <- &types.Job{Row: 1, URL: url}
+	close(d.urls)
+
+	wg.Wait()
+}
+
+func TestDownloadWorker_Stopped(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWriter := typeMocks.NewMockWritable(ctrl)
+	mockReporter := typeMocks.NewMockReportable(ctrl)
+	mockGuard := typeMocks.NewMockGuardable(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := &downloader{
+		ctx:      ctx,
+		logger:   types.NewLoggerStub(),
+		client:   http.DefaultClient,
+		writer:   mockWriter,
+		reporter: mockReporter,
+		cache:    types.NewCacheStub(),
+		guard:    mockGuard,
+		observer: types.NewObserverStub(),
+		tracer:   types.NewTracerStub(),
+		urls:     make(chan *types.Job, 2),
+		lock:     make(chan struct{}, 1),
+	}
+
+	// Once the guard stops the run, the job at hand and every later one is reported, and nothing
+	// else is downloaded.
+	stopErr := failure.New(failure.ClassQuotaExceeded, "output quota exceeded")
+	mockGuard.EXPECT().Wait(ctx).Return(stopErr).Times(1)
+	for _, job := range []struct {
+		row int
+		url string
+	}{{1, "https://example.com/a"}, {2, "https://example.com/b"}, {3, "https://example.com/c"}} {
+		mockReporter.EXPECT().Record(types.ReportEntry{
+			Row:        job.row,
+			URL:        job.url,
+			Status:     types.ReportStatusFailed,
+			Error:      stopErr.Error(),
+			ErrorClass: failure.ClassQuotaExceeded,
+		}).Times(1)
+	}
+	mockWriter.EXPECT().PushForWrite(gomock.Any()).Times(0)
+
+	d.urls <- &types.Job{Row: 1, URL: "https://example.com/a"}
+	d.urls <- &types.Job{Row: 2, URL: "https://example.com/b"}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go d.downloadWorker(wg)
+
+	// The upstream stages are not blocked sending the rest of the rows.
+	d.urls <- &types.Job{Row: 3, URL: "https://example.com/c"}
 	close(d.urls)
+	wg.Wait()
+}
+
+func TestFetchContent_Conditional(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockCache := typeMocks.NewMockCacheable(ctrl)
+	d := &downloader{
+		logger: types.NewLoggerStub(),
+		client: http.DefaultClient,
+		cache:  mockCache,
+	}
+
+	lastModified := "Mon, 19 Oct 2026 10:00:00 GMT"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", lastModified)
+		w.Write([]byte("test content"))
+	}))
+	defer server.Close()
+
+	url := server.URL
+	var stored types.CacheEntry
+
+	// First download: nothing cached yet, validators get stored once written.
+	mockCache.EXPECT().Get(url).Return(types.CacheEntry{}, false)
+	mockCache.EXPECT().Put(url, gomock.Any()).Do(func(url string, entry types.CacheEntry) {
+		stored = entry
+	})
+
+	download, err := d.fetchContent(url)
+	assert.NoError(t, err)
+	assert.False(t, download.NotModified)
+	d.updateCache(download)
+	assert.Equal(t, `"v1"`, stored.ETag)
+	assert.Equal(t, lastModified, stored.LastModified)
+	assert.Equal(t, download.SHA256, stored.SHA256)
+
+	// Second download: validators are sent and the server answers 304.
+	mockCache.EXPECT().Get(url).Return(stored, true)
+
+	download, err = d.fetchContent(url)
+	assert.NoError(t, err)
+	assert.True(t, download.NotModified)
+	assert.Empty(t, download.Content)
+	assert.Equal(t, stored.SHA256, download.SHA256)
+}
+
+func TestDownloadAndPush_Unchanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWriter := typeMocks.NewMockWritable(ctrl)
+	mockCache := typeMocks.NewMockCacheable(ctrl)
+	d := &downloader{
+		logger:   types.NewLoggerStub(),
+		client:   http.DefaultClient,
+		writer:   mockWriter,
+		reporter: types.NewReporterStub(),
+		cache:    mockCache,
+		observer: types.NewObserverStub(),
+		tracer:   types.NewTracerStub(),
+		lock:     make(chan struct{}, 1),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+	defer server.Close()
 
+	mockCache.EXPECT().Get(server.URL).Return(types.CacheEntry{ETag: `"v1"`}, true)
+	mockWriter.EXPECT().PushForWrite(gomock.Any()).Times(0)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	d.lock <- struct{}{}
+	d.downloadAndPush(&types.Job{Row: 1, URL: server.URL}, wg)
 	wg.Wait()
+
+	assert.Equal(t, int32(1), d.stats.unchanged.Load())
+	assert.Equal(t, int32(0), d.stats.downloadSuccessful.Load())
+}
+
+func TestDownloadAndPush_CachesOnceWritten(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockWriter := typeMocks.NewMockWritable(ctrl)
+	mockCache := typeMocks.NewMockCacheable(ctrl)
+	d := &downloader{
+		logger:   types.NewLoggerStub(),
+		client:   http.DefaultClient,
+		writer:   mockWriter,
+		reporter: types.NewReporterStub(),
+		cache:    mockCache,
+		observer: types.NewObserverStub(),
+		tracer:   types.NewTracerStub(),
+		lock:     make(chan struct{}, 1),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("test content"))
+	}))
+	defer server.Close()
+
+	download := func() {
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		d.lock <- struct{}{}
+		d.downloadAndPush(&types.Job{Row: 1, URL: server.URL}, wg)
+		wg.Wait()
+	}
+
+	// The first write fails, so the validators are not cached and the URL is fetched in full again.
+	mockCache.EXPECT().Get(server.URL).Return(types.CacheEntry{}, false).Times(2)
+	mockWriter.EXPECT().PushForWrite(gomock.Any())
+	download()
+
+	var stored types.CacheEntry
+	mockWriter.EXPECT().PushForWrite(gomock.Any()).Do(func(download *types.Download) { download.Written() })
+	mockCache.EXPECT().Put(server.URL, gomock.Any()).Do(func(url string, entry types.CacheEntry) {
+		stored = entry
+	})
+	download()
+
+	assert.Equal(t, `"v1"`, stored.ETag)
+	assert.Equal(t, int32(2), d.stats.downloadSuccessful.Load())
 }
@@ -0,0 +1,90 @@
+package downloader
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/latency"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+// RecentErrors is the number of download failures kept for display.
+const RecentErrors = 5
+
+// hostStats counts the download results per host of the URL in the CSV, times their phases and
+// keeps the latest failures.
+type hostStats struct {
+	mu     sync.Mutex
+	hosts  map[string]*hostCounts
+	errors []types.RecentError
+	phases latency.Phases
+}
+
+// hostCounts are the results of a single host together with the time spent on its requests.
+type hostCounts struct {
+	stats   types.HostStats
+	elapsed time.Duration
+}
+
+// record adds the result of downloading rawURL, which took elapsed.
+func (h *hostStats) record(rawURL string, download *types.Download, elapsed time.Duration, err error) {
+	host := latency.HostOf(rawURL)
+	for phase, duration := range download.Phases {
+		h.phases.Observe(host, phase, duration)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.hosts == nil {
+		h.hosts = make(map[string]*hostCounts)
+	}
+	counts, ok := h.hosts[host]
+	if !ok {
+		counts = &hostCounts{}
+		h.hosts[host] = counts
+	}
+	counts.elapsed += elapsed
+	stats := &counts.stats
+	stats.Requests++
+
+	if err != nil {
+		stats.Failed++
+		if stats.FailedReasons == nil {
+			stats.FailedReasons = make(map[string]int32)
+		}
+		stats.FailedReasons[failure.ClassOf(err)]++
+		h.errors = append(h.errors, types.RecentError{Time: time.Now().UTC(), URL: rawURL, Error: err.Error()})
+		if len(h.errors) > RecentErrors {
+			h.errors = h.errors[len(h.errors)-RecentErrors:]
+		}
+		return
+	}
+	stats.Downloaded++
+	stats.Bytes += int64(len(download.Content))
+}
+
+func (h *hostStats) get() map[string]types.HostStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	phases := h.phases.HostStats()
+	hosts := make(map[string]types.HostStats, len(h.hosts))
+	for host, counts := range h.hosts {
+		stats := counts.stats
+		stats.FailedReasons = maps.Clone(counts.stats.FailedReasons)
+		stats.AvgLatencyMs = float64(counts.elapsed.Microseconds()) / 1000 / float64(stats.Requests)
+		stats.Phases = phases[host]
+		hosts[host] = stats
+	}
+	return hosts
+}
+
+func (h *hostStats) recentErrors() []types.RecentError {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]types.RecentError(nil), h.errors...)
+}
@@ -0,0 +1,78 @@
+package downloader
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostStats(t *testing.T) {
+	h := &hostStats{}
+
+	h.record("https://Example.com/a", &types.Download{Content: []byte("12345")}, 10*time.Millisecond, nil)
+	h.record("https://example.com:8443/b", &types.Download{Content: []byte("123")}, 30*time.Millisecond, nil)
+	h.record("https://other.com/c", &types.Download{}, time.Millisecond, errors.New("bad response"))
+	h.record("::invalid", &types.Download{}, 0, errors.New("invalid"))
+
+	assert.Equal(t, map[string]types.HostStats{
+		"example.com": {Requests: 2, Downloaded: 2, Bytes: 8, AvgLatencyMs: 20},
+		"other.com":   {Requests: 1, Failed: 1, FailedReasons: map[string]int32{failure.ClassOther: 1}, AvgLatencyMs: 1},
+		"unknown":     {Requests: 1, Failed: 1, FailedReasons: map[string]int32{failure.ClassOther: 1}},
+	}, h.get())
+
+	recent := h.recentErrors()
+	assert.Len(t, recent, 2)
+	assert.Equal(t, "https://other.com/c", recent[0].URL)
+	assert.Equal(t, "bad response", recent[0].Error)
+}
+
+func TestHostStats_RecentErrors(t *testing.T) {
+	h := &hostStats{}
+	for i := 0; i < RecentErrors+3; i++ {
+		h.record(fmt.Sprintf("https://example.com/%d", i), &types.Download{}, 0, errors.New("failed"))
+	}
+
+	// Only the latest failures are kept, oldest first.
+	recent := h.recentErrors()
+	assert.Len(t, recent, RecentErrors)
+	assert.Equal(t, "https://example.com/3", recent[0].URL)
+	assert.Equal(t, fmt.Sprintf("https://example.com/%d", RecentErrors+2), recent[RecentErrors-1].URL)
+}
+
+func TestHostStats_Phases(t *testing.T) {
+	h := &hostStats{}
+	h.record("https://example.com/a", &types.Download{Phases: map[string]time.Duration{"ttfb": 10 * time.Millisecond}}, 0, nil)
+	h.record("https://example.com/b", &types.Download{Phases: map[string]time.Duration{"ttfb": 30 * time.Millisecond}}, 0, nil)
+	h.record("https://other.com/c", &types.Download{}, 0, errors.New("failed"))
+
+	hosts := h.get()
+	ttfb := hosts["example.com"].Phases["ttfb"]
+	assert.Equal(t, int64(2), ttfb.Count)
+	assert.Equal(t, 20.0, ttfb.AvgMs)
+	assert.Equal(t, 30.0, ttfb.MaxMs)
+	assert.Nil(t, hosts["other.com"].Phases)
+
+	assert.Equal(t, int64(2), h.phases.Stats()["ttfb"].Count)
+}
+
+func TestHostStats_FailedReasons(t *testing.T) {
+	h := &hostStats{}
+	h.record("https://example.com/a", &types.Download{}, 0, failure.New(failure.ClassHTTP5xx, "502 Bad Gateway"))
+	h.record("https://example.com/b", &types.Download{}, 0, failure.New(failure.ClassHTTP5xx, "503 Service Unavailable"))
+	h.record("https://example.com/c", &types.Download{}, 0, failure.New(failure.ClassTimeout, "timeout"))
+	h.record("https://example.com/d", &types.Download{Content: []byte("1")}, 0, nil)
+
+	stats := h.get()["example.com"]
+	assert.Equal(t, int32(4), stats.Requests)
+	assert.Equal(t, int32(3), stats.Failed)
+	assert.Equal(t, map[string]int32{failure.ClassHTTP5xx: 2, failure.ClassTimeout: 1}, stats.FailedReasons)
+
+	// The returned stats are a copy.
+	stats.FailedReasons[failure.ClassTimeout] = 10
+	assert.Equal(t, int32(1), h.get()["example.com"].FailedReasons[failure.ClassTimeout])
+}
@@ -0,0 +1,103 @@
+package downloader
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+)
+
+// DefaultContentType is assumed for responses without a Content-Type header.
+const DefaultContentType = "application/octet-stream"
+
+var (
+	ErrTooLarge          = failure.New(failure.ClassTooLarge, "response exceeds maximum size")
+	ErrRunQuotaExceeded  = failure.New(failure.ClassQuotaExceeded, "run exceeds maximum total download size")
+	ErrContentTypeDenied = failure.New(failure.ClassContentType, "content type not allowed")
+)
+
+// checkResponse rejects a response by its headers before the body is read.
+func checkResponse(config config.LimitsConfig, resp *http.Response, total int64) error {
+	contentType := mediaType(resp.Header.Get("Content-Type"))
+	if matchContentType(contentType, config.DenyContentTypes) {
+		return fmt.Errorf("%w: %s", ErrContentTypeDenied, contentType)
+	}
+	if len(config.AllowContentTypes) > 0 && !matchContentType(contentType, config.AllowContentTypes) {
+		return fmt.Errorf("%w: %s", ErrContentTypeDenied, contentType)
+	}
+
+	if resp.ContentLength < 0 {
+		return nil
+	}
+	if config.MaxSize > 0 && resp.ContentLength > config.MaxSize {
+		return fmt.Errorf("%w: Content-Length %d > %d", ErrTooLarge, resp.ContentLength, config.MaxSize)
+	}
+	if config.MaxTotalSize > 0 && total+resp.ContentLength > config.MaxTotalSize {
+		return fmt.Errorf("%w: %d bytes left", ErrRunQuotaExceeded, config.MaxTotalSize-total)
+	}
+	return nil
+}
+
+// mediaType returns the lowercased media type of a Content-Type header without parameters.
+func mediaType(contentType string) string {
+	if contentType == "" {
+		return DefaultContentType
+	}
+	parsed, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	return parsed
+}
+
+// matchContentType reports whether contentType matches one of the patterns, which may end in "/*".
+func matchContentType(contentType string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if pattern == contentType || pattern == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// limitedReader fails once the body exceeds the per-URL size or the run total goes over its quota,
+// which covers servers sending no or a wrong Content-Length.
+type limitedReader struct {
+	reader   io.Reader
+	read     int64
+	maxSize  int64
+	total    *atomic.Int64
+	maxTotal int64
+}
+
+func newLimitedReader(reader io.Reader, config config.LimitsConfig, total *atomic.Int64) *limitedReader {
+	return &limitedReader{
+		reader:   reader,
+		maxSize:  config.MaxSize,
+		total:    total,
+		maxTotal: config.MaxTotalSize,
+	}
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.read += int64(n)
+	total := r.total.Add(int64(n))
+
+	if r.maxSize > 0 && r.read > r.maxSize {
+		return n, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, r.maxSize)
+	}
+	if r.maxTotal > 0 && total > r.maxTotal {
+		return n, fmt.Errorf("%w: more than %d bytes", ErrRunQuotaExceeded, r.maxTotal)
+	}
+	return n, err
+}
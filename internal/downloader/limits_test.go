@@ -0,0 +1,112 @@
+package downloader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLimitsTestDownloader(limits config.LimitsConfig) *downloader {
+	return &downloader{
+		config: config.DownloadConfig{Limits: limits},
+		logger: types.NewLoggerStub(),
+		client: http.DefaultClient,
+		cache:  types.NewCacheStub(),
+	}
+}
+
+// newSizedServer serves size bytes at /sized and streams them without Content-Length at /chunked.
+func newSizedServer(size int, contentType string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		body := strings.Repeat("x", size)
+		if r.URL.Path == "/chunked" {
+			w.Write([]byte(body[:size/2]))
+			w.(http.Flusher).Flush()
+			w.Write([]byte(body[size/2:]))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+func TestMatchContentType(t *testing.T) {
+	patterns := []string{"text/html", "IMAGE/*"}
+
+	assert.True(t, matchContentType(mediaType("text/html; charset=utf-8"), patterns))
+	assert.True(t, matchContentType(mediaType("image/png"), patterns))
+	assert.False(t, matchContentType(mediaType("text/plain"), patterns))
+	assert.False(t, matchContentType(mediaType("imagex/png"), patterns))
+	assert.True(t, matchContentType(mediaType(""), []string{DefaultContentType}))
+	assert.True(t, matchContentType("anything/else", []string{"*/*"}))
+}
+
+func TestFetchContent_MaxSize(t *testing.T) {
+	server := newSizedServer(100, "text/plain")
+	defer server.Close()
+
+	d := newLimitsTestDownloader(config.LimitsConfig{MaxSize: 50})
+
+	_, err := d.fetchContent(server.URL + "/sized")
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = d.fetchContent(server.URL + "/chunked")
+	assert.ErrorIs(t, err, ErrTooLarge)
+	assert.Equal(t, int32(2), d.stats.tooLarge.Load())
+
+	d = newLimitsTestDownloader(config.LimitsConfig{MaxSize: 100})
+	download, err := d.fetchContent(server.URL + "/chunked")
+	assert.NoError(t, err)
+	assert.Len(t, download.Content, 100)
+}
+
+func TestFetchContent_MaxTotalSize(t *testing.T) {
+	server := newSizedServer(40, "text/plain")
+	defer server.Close()
+
+	d := newLimitsTestDownloader(config.LimitsConfig{MaxTotalSize: 100})
+
+	_, err := d.fetchContent(server.URL + "/sized")
+	assert.NoError(t, err)
+	_, err = d.fetchContent(server.URL + "/chunked")
+	assert.NoError(t, err)
+
+	// 80 bytes used, the next 40 bytes do not fit anymore.
+	_, err = d.fetchContent(server.URL + "/sized")
+	assert.ErrorIs(t, err, ErrRunQuotaExceeded)
+	_, err = d.fetchContent(server.URL + "/chunked")
+	assert.ErrorIs(t, err, ErrRunQuotaExceeded)
+
+	// Once the quota is used up, URLs are skipped without a request.
+	_, err = d.fetchContent(server.URL + "/sized")
+	assert.ErrorIs(t, err, ErrRunQuotaExceeded)
+	assert.Equal(t, int32(3), d.stats.quotaExceeded.Load())
+}
+
+func TestFetchContent_ContentType(t *testing.T) {
+	html := newSizedServer(10, "text/html; charset=utf-8")
+	defer html.Close()
+	iso := newSizedServer(10, "application/x-iso9660-image")
+	defer iso.Close()
+
+	d := newLimitsTestDownloader(config.LimitsConfig{
+		AllowContentTypes: []string{"text/*", "application/*"},
+		DenyContentTypes:  []string{"application/x-iso9660-image"},
+	})
+
+	_, err := d.fetchContent(html.URL)
+	assert.NoError(t, err)
+
+	_, err = d.fetchContent(iso.URL)
+	assert.ErrorIs(t, err, ErrContentTypeDenied)
+
+	d = newLimitsTestDownloader(config.LimitsConfig{AllowContentTypes: []string{"image/*"}})
+	_, err = d.fetchContent(html.URL)
+	assert.ErrorIs(t, err, ErrContentTypeDenied)
+	assert.Equal(t, int32(1), d.stats.contentTypeDenied.Load())
+}
@@ -0,0 +1,65 @@
+package downloader
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+)
+
+const DefaultMaxRedirects = 10
+
+var (
+	ErrTooManyRedirects  = failure.New(failure.ClassRedirectBlocked, "too many redirects")
+	ErrSchemeDowngrade   = failure.New(failure.ClassRedirectBlocked, "redirect downgrades https to http")
+	ErrCrossHostRedirect = failure.New(failure.ClassRedirectBlocked, "redirect to another host")
+)
+
+type redirectPolicy struct {
+	maxRedirects    int
+	forbidDowngrade bool
+	forbidCrossHost bool
+}
+
+func newRedirectPolicy(config config.RedirectConfig) *redirectPolicy {
+	maxRedirects := DefaultMaxRedirects
+	if config.MaxRedirects != nil {
+		maxRedirects = *config.MaxRedirects
+	}
+
+	return &redirectPolicy{
+		maxRedirects:    maxRedirects,
+		forbidDowngrade: config.ForbidDowngrade,
+		forbidCrossHost: config.ForbidCrossHost,
+	}
+}
+
+// check is used as http.Client.CheckRedirect and is called before following every redirect.
+func (p *redirectPolicy) check(req *http.Request, via []*http.Request) error {
+	if len(via) > p.maxRedirects {
+		return fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, p.maxRedirects)
+	}
+
+	prev := via[len(via)-1].URL
+	if p.forbidDowngrade && prev.Scheme == "https" && req.URL.Scheme == "http" {
+		return fmt.Errorf("%w: %s -> %s", ErrSchemeDowngrade, prev, req.URL)
+	}
+
+	origin := via[0].URL
+	if p.forbidCrossHost && !strings.EqualFold(origin.Hostname(), req.URL.Hostname()) {
+		return fmt.Errorf("%w: %s -> %s", ErrCrossHostRedirect, origin.Hostname(), req.URL.Hostname())
+	}
+
+	return nil
+}
+
+// redirectChain returns the URLs requested before the one that produced resp, oldest first.
+func redirectChain(resp *http.Response) []string {
+	var chain []string
+	for req := resp.Request; req.Response != nil; req = req.Response.Request {
+		chain = append([]string{req.Response.Request.URL.String()}, chain...)
+	}
+	return chain
+}
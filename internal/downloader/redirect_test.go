@@ -0,0 +1,114 @@
+package downloader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRedirectTestDownloader(t *testing.T, redirect config.RedirectConfig) *downloader {
+	client, err := newHTTPClient(config.DownloadConfig{
+		Redirect: redirect,
+		TLS: config.TLSConfig{Hosts: map[string]config.HostTLSConfig{
+			"127.0.0.1": {InsecureSkipVerify: true},
+		}},
+		SSRF: allowLoopback,
+	}, types.NewLoggerStub())
+	assert.NoError(t, err)
+
+	return &downloader{
+		logger: types.NewLoggerStub(),
+		client: client,
+		cache:  types.NewCacheStub(),
+	}
+}
+
+// newRedirectingServer redirects /hop/N to /hop/N-1 and serves content at /hop/0.
+func newRedirectingServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hop/0" {
+			w.Write([]byte("final"))
+			return
+		}
+		next := map[string]string{"/hop/1": "/hop/0", "/hop/2": "/hop/1", "/hop/3": "/hop/2"}[r.URL.Path]
+		http.Redirect(w, r, next, http.StatusFound)
+	}))
+}
+
+func TestFetchContent_RedirectChain(t *testing.T) {
+	server := newRedirectingServer()
+	defer server.Close()
+
+	d := newRedirectTestDownloader(t, config.RedirectConfig{})
+
+	download, err := d.fetchContent(server.URL + "/hop/2")
+	assert.NoError(t, err)
+	assert.Equal(t, "final", string(download.Content))
+	assert.Equal(t, server.URL+"/hop/0", download.FinalURL)
+	assert.Equal(t, []string{server.URL + "/hop/2", server.URL + "/hop/1"}, download.Redirects)
+	assert.Equal(t, int32(1), d.stats.redirected.Load())
+}
+
+func TestFetchContent_MaxRedirects(t *testing.T) {
+	server := newRedirectingServer()
+	defer server.Close()
+
+	maxRedirects := 2
+	d := newRedirectTestDownloader(t, config.RedirectConfig{MaxRedirects: &maxRedirects})
+
+	_, err := d.fetchContent(server.URL + "/hop/2")
+	assert.NoError(t, err)
+
+	download, err := d.fetchContent(server.URL + "/hop/3")
+	assert.ErrorIs(t, err, ErrTooManyRedirects)
+	assert.Equal(t, server.URL+"/hop/1", download.FinalURL)
+	assert.Equal(t, int32(1), d.stats.redirectBlocked.Load())
+
+	noRedirects := 0
+	d = newRedirectTestDownloader(t, config.RedirectConfig{MaxRedirects: &noRedirects})
+	_, err = d.fetchContent(server.URL + "/hop/1")
+	assert.ErrorIs(t, err, ErrTooManyRedirects)
+}
+
+func TestFetchContent_ForbidDowngrade(t *testing.T) {
+	plain := newRedirectingServer()
+	defer plain.Close()
+
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL+"/hop/0", http.StatusFound)
+	}))
+	defer secure.Close()
+
+	d := newRedirectTestDownloader(t, config.RedirectConfig{})
+	_, err := d.fetchContent(secure.URL)
+	assert.NoError(t, err)
+
+	d = newRedirectTestDownloader(t, config.RedirectConfig{ForbidDowngrade: true})
+	_, err = d.fetchContent(secure.URL)
+	assert.ErrorIs(t, err, ErrSchemeDowngrade)
+}
+
+func TestFetchContent_ForbidCrossHost(t *testing.T) {
+	target := newRedirectingServer()
+	defer target.Close()
+
+	// 127.0.0.1 and localhost point at the same server but are different hosts.
+	otherHost := strings.Replace(target.URL, "127.0.0.1", "localhost", 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, otherHost+"/hop/0", http.StatusFound)
+	}))
+	defer server.Close()
+
+	d := newRedirectTestDownloader(t, config.RedirectConfig{ForbidCrossHost: true})
+
+	_, err := d.fetchContent(target.URL + "/hop/1")
+	assert.NoError(t, err)
+
+	_, err = d.fetchContent(server.URL)
+	assert.ErrorIs(t, err, ErrCrossHostRedirect)
+}
@@ -0,0 +1,121 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"syscall"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+)
+
+var ErrBlockedDestination = failure.New(failure.ClassDestinationBlocked, "destination address is not allowed")
+
+// blockedPrefixes are the internal ranges not already covered by the netip.Addr helpers.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"), // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),  // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"), // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),   // reserved, including broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),  // NAT64, may embed any IPv4 address
+}
+
+type dialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// dialGuard rejects connections to internal addresses once the host name has been resolved,
+// so it also applies to redirects and to names resolving to internal addresses.
+type dialGuard struct {
+	guarded    *net.Dialer
+	unguarded  *net.Dialer
+	allowHosts map[string]struct{}
+	allowCIDRs []netip.Prefix
+}
+
+// newDialFunc returns the dial function used by the transport, guarded unless the guard is disabled.
+func newDialFunc(config config.SSRFConfig, dialer *net.Dialer) (dialFunc, error) {
+	if config.Disabled {
+		return dialer.DialContext, nil
+	}
+
+	guard := &dialGuard{
+		unguarded:  dialer,
+		allowHosts: make(map[string]struct{}, len(config.AllowHosts)),
+	}
+
+	for _, host := range config.AllowHosts {
+		guard.allowHosts[strings.ToLower(host)] = struct{}{}
+	}
+
+	for _, cidr := range config.AllowCIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allowed CIDR %s: %w", cidr, err)
+		}
+		guard.allowCIDRs = append(guard.allowCIDRs, prefix)
+	}
+
+	guarded := *dialer
+	guarded.Control = guard.control
+	guard.guarded = &guarded
+
+	return guard.DialContext, nil
+}
+
+// DialContext dials allowlisted host names directly and everything else through the guard.
+func (g *dialGuard) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := g.allowHosts[strings.ToLower(host)]; ok {
+		return g.unguarded.DialContext(ctx, network, addr)
+	}
+	return g.guarded.DialContext(ctx, network, addr)
+}
+
+// control is called with the resolved address right before connecting.
+func (g *dialGuard) control(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedDestination, host)
+	}
+	addr = addr.Unmap()
+
+	for _, prefix := range g.allowCIDRs {
+		if prefix.Contains(addr) {
+			return nil
+		}
+	}
+
+	if isInternalAddr(addr) {
+		return fmt.Errorf("%w: %s", ErrBlockedDestination, addr)
+	}
+	return nil
+}
+
+// isInternalAddr reports whether addr is loopback, private, link-local (including cloud
+// metadata endpoints), multicast, unspecified or otherwise reserved.
+func isInternalAddr(addr netip.Addr) bool {
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return true
+	}
+
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package downloader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsInternalAddr(t *testing.T) {
+	tests := []struct {
+		addr     string
+		internal bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"255.255.255.255", true},
+		{"224.0.0.1", true},
+		{"::1", true},
+		{"fe80::1", true},
+		{"fd00:ec2::254", true},
+		{"8.8.8.8", false},
+		{"93.184.216.34", false},
+		{"2606:4700:4700::1111", false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.internal, isInternalAddr(netip.MustParseAddr(test.addr)), test.addr)
+	}
+}
+
+func TestFetchContent_BlockedDestination(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	client, err := newHTTPClient(config.DownloadConfig{}, types.NewLoggerStub())
+	assert.NoError(t, err)
+	d := &downloader{logger: types.NewLoggerStub(), client: client, cache: types.NewCacheStub()}
+
+	_, err = d.fetchContent(server.URL)
+	assert.ErrorIs(t, err, ErrBlockedDestination)
+	assert.Equal(t, int32(1), d.stats.destinationBlocked.Load())
+}
+
+func TestFetchContent_BlockedRedirect(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer internal.Close()
+
+	// The allowlisted host name redirects to a literal internal address, which must still be blocked.
+	entry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL, http.StatusFound)
+	}))
+	defer entry.Close()
+	entryURL := strings.Replace(entry.URL, "127.0.0.1", "localhost", 1)
+
+	client, err := newHTTPClient(config.DownloadConfig{
+		SSRF: config.SSRFConfig{AllowHosts: []string{"LOCALHOST"}},
+	}, types.NewLoggerStub())
+	assert.NoError(t, err)
+	d := &downloader{logger: types.NewLoggerStub(), client: client, cache: types.NewCacheStub()}
+
+	_, err = d.fetchContent(entryURL)
+	assert.ErrorIs(t, err, ErrBlockedDestination)
+}
+
+func TestFetchContent_AllowedCIDR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("internal"))
+	}))
+	defer server.Close()
+
+	client, err := newHTTPClient(config.DownloadConfig{
+		SSRF: config.SSRFConfig{AllowCIDRs: []string{"127.0.0.0/8"}},
+	}, types.NewLoggerStub())
+	assert.NoError(t, err)
+	d := &downloader{logger: types.NewLoggerStub(), client: client, cache: types.NewCacheStub()}
+
+	download, err := d.fetchContent(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, "internal", string(download.Content))
+}
+
+func TestNewHTTPClient_IgnoresProxy(t *testing.T) {
+	proxied := 0
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied++
+		w.Write([]byte("internal"))
+	}))
+	defer proxy.Close()
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("http_proxy", proxy.URL)
+
+	// The proxy itself is allowed, the internal destination behind it is not.
+	client, err := newHTTPClient(config.DownloadConfig{
+		SSRF: config.SSRFConfig{AllowCIDRs: []string{"127.0.0.0/8"}},
+	}, types.NewLoggerStub())
+	assert.NoError(t, err)
+	assert.Nil(t, client.Transport.(*http.Transport).Proxy)
+
+	d := &downloader{logger: types.NewLoggerStub(), client: client, cache: types.NewCacheStub()}
+	_, err = d.fetchContent("http://169.254.169.254/latest/meta-data/")
+	assert.ErrorIs(t, err, ErrBlockedDestination)
+	assert.Zero(t, proxied)
+
+	// Without the guard the proxy from the environment is used as usual.
+	client, err = newHTTPClient(config.DownloadConfig{SSRF: config.SSRFConfig{Disabled: true}}, types.NewLoggerStub())
+	assert.NoError(t, err)
+	assert.NotNil(t, client.Transport.(*http.Transport).Proxy)
+}
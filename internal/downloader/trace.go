@@ -0,0 +1,123 @@
+package downloader
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/latency"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// phaseTrace times the phases of a request and of the redirects it follows. The hooks of
+// httptrace may be called from several goroutines, when dialing multiple addresses at once.
+type phaseTrace struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	connectStart map[string]time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	phases       map[string]time.Duration
+}
+
+func newPhaseTrace() *phaseTrace {
+	return &phaseTrace{
+		connectStart: make(map[string]time.Time),
+		phases:       make(map[string]time.Duration),
+	}
+}
+
+// clientTrace returns the hooks to attach to the request context.
+func (t *phaseTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { t.start(&t.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { t.done(latency.PhaseDNS, &t.dnsStart) },
+		ConnectStart: func(network, addr string) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			t.connectStart[network+addr] = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			start := t.connectStart[network+addr]
+			delete(t.connectStart, network+addr)
+			// Only the attempt that connected counts, the others lost the race or failed.
+			if err == nil {
+				t.add(latency.PhaseConnect, start, time.Now())
+			}
+		},
+		TLSHandshakeStart:    func() { t.start(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.done(latency.PhaseTLS, &t.tlsStart) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.start(&t.wroteRequest) },
+		GotFirstResponseByte: func() { t.start(&t.firstByte); t.done(latency.PhaseTTFB, &t.wroteRequest) },
+	}
+}
+
+func (t *phaseTrace) start(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	*at = time.Now()
+}
+
+// done adds the time since *start to phase.
+func (t *phaseTrace) done(phase string, start *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.add(phase, *start, time.Now())
+}
+
+// add adds the time from start to end to phase, and must be called with mu held.
+func (t *phaseTrace) add(phase string, start, end time.Time) {
+	if !start.IsZero() {
+		t.phases[phase] += end.Sub(start)
+	}
+}
+
+// bodyRead ends the transfer phase, from the first byte of the final response to the last.
+func (t *phaseTrace) bodyRead() {
+	t.done(latency.PhaseTransfer, &t.firstByte)
+}
+
+// get returns the phases timed so far.
+func (t *phaseTrace) get() map[string]time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	phases := make(map[string]time.Duration, len(t.phases))
+	for phase, d := range t.phases {
+		phases[phase] = d
+	}
+	return phases
+}
+
+// endDownloadSpan ends the download span in ctx with the outcome of the request and its phases.
+func endDownloadSpan(ctx context.Context, download *types.Download, err error) {
+	attrs := []attribute.KeyValue{
+		attribute.Int("download.redirects", len(download.Redirects)),
+		attribute.Bool("download.not_modified", download.NotModified),
+		semconv.HTTPResponseBodySize(len(download.Content)),
+	}
+	if download.StatusCode != 0 {
+		attrs = append(attrs, semconv.HTTPResponseStatusCode(download.StatusCode))
+	}
+	if download.FinalURL != "" && download.FinalURL != download.URL {
+		attrs = append(attrs, attribute.String("download.final_url", download.FinalURL))
+	}
+	for phase, duration := range download.Phases {
+		attrs = append(attrs, attribute.Float64("download.phase."+phase+"_ms", float64(duration)/float64(time.Millisecond)))
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attrs...)
+	if err != nil {
+		types.FailSpan(ctx, err)
+	}
+	span.End()
+}
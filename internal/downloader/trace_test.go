@@ -0,0 +1,203 @@
+package downloader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/latency"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	typeMocks "github.com/puruabhi/jfrog/home-assignment/internal/types/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordingTracer keeps the spans in memory for the tests to inspect.
+type recordingTracer struct {
+	tracer   trace.Tracer
+	recorder *tracetest.SpanRecorder
+}
+
+func newRecordingTracer() *recordingTracer {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	return &recordingTracer{tracer: provider.Tracer("test"), recorder: recorder}
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, name, opts...)
+}
+
+func (r *recordingTracer) Close() error { return nil }
+
+// ended returns the ended spans by name.
+func (r *recordingTracer) ended() map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range r.recorder.Ended() {
+		spans[span.Name()] = span
+	}
+	return spans
+}
+
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestFetch_Phases(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("test content"))
+	}))
+	defer server.Close()
+
+	d := &downloader{
+		logger: types.NewLoggerStub(),
+		client: server.Client(),
+		cache:  types.NewCacheStub(),
+	}
+
+	download, err := d.fetchContent(server.URL)
+	assert.NoError(t, err)
+	assert.Contains(t, download.Phases, latency.PhaseConnect)
+	assert.Contains(t, download.Phases, latency.PhaseTLS)
+	assert.Contains(t, download.Phases, latency.PhaseTransfer)
+	assert.GreaterOrEqual(t, download.Phases[latency.PhaseTTFB], 20*time.Millisecond)
+	// The server is addressed by IP, so there is nothing to look up.
+	assert.NotContains(t, download.Phases, latency.PhaseDNS)
+
+	// The connection is reused for the next request.
+	download, err = d.fetchContent(server.URL)
+	assert.NoError(t, err)
+	assert.NotContains(t, download.Phases, latency.PhaseConnect)
+	assert.NotContains(t, download.Phases, latency.PhaseTLS)
+	assert.Contains(t, download.Phases, latency.PhaseTTFB)
+}
+
+func TestFetch_PhasesOnError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	d := &downloader{
+		logger: types.NewLoggerStub(),
+		client: http.DefaultClient,
+		cache:  types.NewCacheStub(),
+	}
+
+	// Failed downloads are timed up to the failure.
+	download, err := d.fetchContent(server.URL)
+	assert.Error(t, err)
+	assert.Contains(t, download.Phases, latency.PhaseTTFB)
+	assert.NotContains(t, download.Phases, latency.PhaseTransfer)
+}
+
+func TestDownloadAndPush_Spans(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("test content"))
+	}))
+	defer server.Close()
+
+	tracer := newRecordingTracer()
+	mockWriter := typeMocks.NewMockWritable(ctrl)
+	d := &downloader{
+		logger:   types.NewLoggerStub(),
+		client:   http.DefaultClient,
+		writer:   mockWriter,
+		reporter: types.NewReporterStub(),
+		cache:    types.NewCacheStub(),
+		observer: types.NewObserverStub(),
+		tracer:   tracer,
+		lock:     make(chan struct{}, 1),
+	}
+
+	download := func(url string) {
+		jobCtx, _ := tracer.Start(context.Background(), "job")
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		d.lock <- struct{}{}
+		d.downloadAndPush(&types.Job{Row: 1, URL: url, Ctx: jobCtx}, wg)
+	}
+
+	// A successful download hands the job span over to the writer.
+	var pushed *types.Download
+	mockWriter.EXPECT().PushForWrite(gomock.Any()).Do(func(download *types.Download) { pushed = download })
+	download(server.URL)
+
+	spans := tracer.ended()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, codes.Unset, spans["download"].Status().Code)
+	attrs := attributes(spans["download"])
+	assert.Equal(t, int64(http.StatusOK), attrs["http.response.status_code"].AsInt64())
+	assert.Equal(t, int64(len("test content")), attrs["http.response.body.size"].AsInt64())
+	assert.Contains(t, attrs, attribute.Key("download.phase.ttfb_ms"))
+	assert.True(t, trace.SpanFromContext(pushed.Context()).IsRecording())
+
+	// A failed download ends the job, marked as failed.
+	download(server.URL + "/missing")
+
+	spans = tracer.ended()
+	assert.Equal(t, codes.Error, spans["download"].Status().Code)
+	assert.Equal(t, codes.Error, spans["job"].Status().Code)
+	assert.Equal(t, types.ReportStatusFailed, attributes(spans["job"])["job.status"].AsString())
+	assert.Equal(t, spans["job"].SpanContext().SpanID(), spans["download"].Parent().SpanID())
+}
+
+func TestDownloadWorker_StoppedEndsJobs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockGuard := typeMocks.NewMockGuardable(ctrl)
+	tracer := newRecordingTracer()
+	d := &downloader{
+		ctx:      context.Background(),
+		logger:   types.NewLoggerStub(),
+		reporter: types.NewReporterStub(),
+		guard:    mockGuard,
+		tracer:   tracer,
+		urls:     make(chan *types.Job, 2),
+		lock:     make(chan struct{}, 1),
+	}
+
+	stopErr := failure.New(failure.ClassDiskSpace, "low disk space")
+	mockGuard.EXPECT().Wait(gomock.Any()).Return(stopErr).Times(1)
+
+	// The job stopped at and the ones drained after it all end their spans.
+	for row := 1; row <= 2; row++ {
+		ctx, _ := tracer.Start(context.Background(), "job")
+		d.urls <- &types.Job{Row: row, URL: "https://example.com/", Ctx: ctx}
+	}
+	close(d.urls)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	d.downloadWorker(wg)
+	wg.Wait()
+
+	ended := tracer.recorder.Ended()
+	assert.Len(t, ended, 2)
+	for _, span := range ended {
+		assert.Equal(t, codes.Error, span.Status().Code)
+		assert.Equal(t, types.ReportStatusFailed, attributes(span)["job.status"].AsString())
+	}
+}
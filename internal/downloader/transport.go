@@ -0,0 +1,178 @@
+package downloader
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsDialer dials TLS connections using the configuration matching the destination host.
+type tlsDialer struct {
+	dial  dialFunc
+	base  *tls.Config
+	hosts map[string]*tls.Config
+}
+
+// newHTTPClient builds the HTTP client used for downloads from the download config.
+func newHTTPClient(config config.DownloadConfig, logger types.Logger) (*http.Client, error) {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	dial, err := newDialFunc(config.SSRF, dialer)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsDial, err := newTLSDialer(config.TLS, dial, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Through a proxy the guard would only ever see the proxy's address, so while it is enabled
+	// every request goes directly to its destination.
+	if !config.SSRF.Disabled {
+		if proxyFromEnvironment() {
+			logger.Warnf("Ignoring the HTTP proxy from the environment while SSRF protection is enabled")
+		}
+		transport.Proxy = nil
+	}
+	transport.DialContext = dial
+	transport.DialTLSContext = tlsDial.DialTLSContext
+	transport.TLSClientConfig = tlsDial.base
+
+	return &http.Client{
+		Transport:     transport,
+		CheckRedirect: newRedirectPolicy(config.Redirect).check,
+	}, nil
+}
+
+// proxyFromEnvironment reports whether an HTTP proxy is configured through the environment.
+func proxyFromEnvironment() bool {
+	for _, name := range []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy"} {
+		if os.Getenv(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// newTLSDialer prepares the base TLS config and the per-host overrides.
+func newTLSDialer(config config.TLSConfig, dial dialFunc, logger types.Logger) (*tlsDialer, error) {
+	base := &tls.Config{}
+
+	if config.MinVersion != "" {
+		version, ok := tlsVersions[config.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS version: %s", config.MinVersion)
+		}
+		base.MinVersion = version
+	}
+
+	if len(config.RootCAFiles) > 0 {
+		rootCAs, err := loadRootCAs(config.RootCAFiles)
+		if err != nil {
+			return nil, err
+		}
+		base.RootCAs = rootCAs
+	}
+
+	hosts := make(map[string]*tls.Config, len(config.Hosts))
+	for host, hostConfig := range config.Hosts {
+		hostTLS := base.Clone()
+
+		if hostConfig.CertFile != "" {
+			cert, err := tls.LoadX509KeyPair(hostConfig.CertFile, hostConfig.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load client certificate for host %s: %w", host, err)
+			}
+			hostTLS.Certificates = []tls.Certificate{cert}
+		}
+
+		if hostConfig.InsecureSkipVerify {
+			logger.Warnf("TLS certificate verification is DISABLED for host %s", host)
+			hostTLS.InsecureSkipVerify = true
+		}
+
+		hosts[host] = hostTLS
+	}
+
+	return &tlsDialer{
+		dial:  dial,
+		base:  base,
+		hosts: hosts,
+	}, nil
+}
+
+// loadRootCAs returns the system cert pool extended with the certificates in the given PEM files.
+func loadRootCAs(files []string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	for _, file := range files {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read root CA file %s: %w", file, err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in root CA file %s", file)
+		}
+	}
+
+	return pool, nil
+}
+
+// configFor returns the TLS config for the given address, preferring a "host:port" match over "host".
+func (t *tlsDialer) configFor(addr, host string) *tls.Config {
+	cfg, ok := t.hosts[addr]
+	if !ok {
+		cfg, ok = t.hosts[host]
+	}
+	if !ok {
+		cfg = t.base
+	}
+
+	cfg = cfg.Clone()
+	cfg.ServerName = host
+	return cfg
+}
+
+// DialTLSContext dials the address and performs the TLS handshake with the matching host config.
+func (t *tlsDialer) DialTLSContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := t.dial(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConn := tls.Client(conn, t.configFor(addr, host))
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
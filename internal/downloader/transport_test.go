@@ -0,0 +1,150 @@
+package downloader
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// allowLoopback lets test clients reach httptest servers through the SSRF guard.
+var allowLoopback = config.SSRFConfig{AllowCIDRs: []string{"127.0.0.0/8", "::1/128"}}
+
+func newTLSTestServer() *httptest.Server {
+	return httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	assert.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(block), 0644))
+	return caFile
+}
+
+func writeClientKeyPair(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "test-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "client.pem")
+	keyFile := filepath.Join(dir, "client-key.pem")
+	assert.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+	assert.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+func TestNewHTTPClient_RootCA(t *testing.T) {
+	server := newTLSTestServer()
+	defer server.Close()
+
+	client, err := newHTTPClient(config.DownloadConfig{SSRF: allowLoopback}, types.NewLoggerStub())
+	assert.NoError(t, err)
+	_, err = client.Get(server.URL)
+	assert.Error(t, err)
+
+	client, err = newHTTPClient(config.DownloadConfig{
+		TLS:  config.TLSConfig{RootCAFiles: []string{writeServerCA(t, server)}},
+		SSRF: allowLoopback,
+	}, types.NewLoggerStub())
+	assert.NoError(t, err)
+
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestNewHTTPClient_InsecureHost(t *testing.T) {
+	server := newTLSTestServer()
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	client, err := newHTTPClient(config.DownloadConfig{
+		TLS: config.TLSConfig{Hosts: map[string]config.HostTLSConfig{
+			serverURL.Hostname(): {InsecureSkipVerify: true},
+		}},
+		SSRF: allowLoopback,
+	}, types.NewLoggerStub())
+	assert.NoError(t, err)
+
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestNewHTTPClient_ClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := writeServerCA(t, server)
+	certFile, keyFile := writeClientKeyPair(t)
+	serverURL, _ := url.Parse(server.URL)
+
+	client, err := newHTTPClient(config.DownloadConfig{
+		TLS:  config.TLSConfig{RootCAFiles: []string{caFile}},
+		SSRF: allowLoopback,
+	}, types.NewLoggerStub())
+	assert.NoError(t, err)
+	_, err = client.Get(server.URL)
+	assert.Error(t, err)
+
+	client, err = newHTTPClient(config.DownloadConfig{
+		TLS: config.TLSConfig{
+			RootCAFiles: []string{caFile},
+			Hosts: map[string]config.HostTLSConfig{
+				serverURL.Host: {CertFile: certFile, KeyFile: keyFile},
+			},
+		},
+		SSRF: allowLoopback,
+	}, types.NewLoggerStub())
+	assert.NoError(t, err)
+
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestNewHTTPClient_Errors(t *testing.T) {
+	_, err := newHTTPClient(config.DownloadConfig{
+		TLS: config.TLSConfig{MinVersion: "0.9"},
+	}, types.NewLoggerStub())
+	assert.Error(t, err)
+
+	_, err = newHTTPClient(config.DownloadConfig{
+		TLS: config.TLSConfig{RootCAFiles: []string{"/does/not/exist.pem"}},
+	}, types.NewLoggerStub())
+	assert.Error(t, err)
+}
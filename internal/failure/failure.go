@@ -0,0 +1,108 @@
+package failure
+
+import (
+	"errors"
+	"sync"
+)
+
+// Classes a URL can fail with, as counted in the stats and recorded in the report.
+const (
+	ClassDNS               = "dns"
+	ClassConnectionRefused = "connection_refused"
+	ClassConnection        = "connection"
+	ClassTimeout           = "timeout"
+	ClassTLS               = "tls"
+	ClassHTTP4xx           = "http_4xx"
+	ClassHTTP5xx           = "http_5xx"
+	// ClassHTTPStatus is any other status than 200, such as a 204 or an unfollowed 3xx.
+	ClassHTTPStatus         = "http_status"
+	ClassRedirectBlocked    = "redirect_blocked"
+	ClassDestinationBlocked = "destination_blocked"
+	ClassTooLarge           = "too_large"
+	ClassQuotaExceeded      = "quota_exceeded"
+	ClassContentType        = "content_type_denied"
+	ClassInvalid            = "invalid"
+	ClassFiltered           = "filtered"
+	ClassDiskSpace          = "disk_space"
+	ClassDecode             = "decode_error"
+	ClassWrite              = "write_error"
+	ClassUpload             = "upload_error"
+	ClassCanceled           = "canceled"
+	ClassOther              = "other"
+)
+
+// Error is an error classified by the stage it happened in. The class survives wrapping.
+type Error struct {
+	Class string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error with the given text classified as class, for sentinel errors.
+func New(class, text string) error {
+	return &Error{Class: class, Err: errors.New(text)}
+}
+
+// Classify returns err classified as class, or nil for a nil err. An err that already carries a
+// class keeps it, as it was classified closer to its cause.
+func Classify(class string, err error) error {
+	var classified *Error
+	if err == nil || errors.As(err, &classified) {
+		return err
+	}
+	return &Error{Class: class, Err: err}
+}
+
+// ClassOf returns the class of err, ClassOther when it was not classified, or "" for a nil err.
+func ClassOf(err error) string {
+	if err == nil {
+		return ""
+	}
+	var classified *Error
+	if errors.As(err, &classified) {
+		return classified.Class
+	}
+	return ClassOther
+}
+
+// Counts counts errors by class. The zero value is ready to use.
+type Counts struct {
+	mu     sync.Mutex
+	counts map[string]int32
+}
+
+// Add counts err under its class and returns the class.
+func (c *Counts) Add(err error) string {
+	class := ClassOf(err)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.counts == nil {
+		c.counts = make(map[string]int32)
+	}
+	c.counts[class]++
+	return class
+}
+
+// Get returns a copy of the counts, or nil when nothing was counted.
+func (c *Counts) Get() map[string]int32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.counts) == 0 {
+		return nil
+	}
+	counts := make(map[string]int32, len(c.counts))
+	for class, count := range c.counts {
+		counts[class] = count
+	}
+	return counts
+}
@@ -0,0 +1,42 @@
+package failure
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassOf(t *testing.T) {
+	base := errors.New("connection refused")
+	err := fmt.Errorf("caught err while fetching: %w", Classify(ClassConnectionRefused, base))
+
+	assert.Equal(t, ClassConnectionRefused, ClassOf(err))
+	assert.ErrorIs(t, err, base)
+	assert.Equal(t, "caught err while fetching: connection refused", err.Error())
+	assert.Equal(t, ClassOther, ClassOf(base))
+	assert.Equal(t, "", ClassOf(nil))
+	assert.Nil(t, Classify(ClassTimeout, nil))
+
+	// The class given closest to the cause wins.
+	assert.Equal(t, ClassConnectionRefused, ClassOf(Classify(ClassOther, err)))
+	sentinel := New(ClassTooLarge, "too large")
+	assert.Equal(t, ClassTooLarge, ClassOf(fmt.Errorf("rejected: %w", sentinel)))
+	assert.ErrorIs(t, fmt.Errorf("rejected: %w", sentinel), sentinel)
+}
+
+func TestCounts(t *testing.T) {
+	var counts Counts
+	assert.Nil(t, counts.Get())
+
+	assert.Equal(t, ClassTimeout, counts.Add(Classify(ClassTimeout, errors.New("timeout"))))
+	counts.Add(Classify(ClassTimeout, errors.New("timeout")))
+	counts.Add(errors.New("unknown"))
+
+	got := counts.Get()
+	assert.Equal(t, map[string]int32{ClassTimeout: 2, ClassOther: 1}, got)
+
+	got[ClassTimeout] = 10
+	assert.Equal(t, int32(2), counts.Get()[ClassTimeout])
+}
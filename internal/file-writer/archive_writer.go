@@ -0,0 +1,293 @@
+package filewriter
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/latency"
+	"github.com/puruabhi/jfrog/home-assignment/internal/manifest"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+// archive appends entries to a tar or zip stream.
+type archive interface {
+	add(name string, content []byte, modTime time.Time) error
+	close() error
+}
+
+type archiveWriter struct {
+	ctx       context.Context
+	config    config.WriteConfig
+	logger    types.Logger
+	reporter  types.Reportable
+	manifest  types.Manifestable
+	guard     types.Guardable
+	observer  types.Observable
+	tracer    types.Traceable
+	file      *os.File
+	archive   archive
+	entries   []types.ManifestEntry
+	names     map[string]struct{}
+	writeChan chan *types.Download
+	closeOnce sync.Once
+	done      chan struct{}
+	phases    latency.Phases
+	failures  failure.Counts
+
+	// stat variables
+	stats struct {
+		writeFailed  atomic.Int32
+		writing      atomic.Int32
+		writeSuccess atomic.Int32
+		bytesWritten atomic.Int64
+	}
+}
+
+// NewArchiveWriter creates the archive as a temp file and starts the writer goroutine appending every
+// download to it. The archive only appears under its final name once Close finished it.
+func NewArchiveWriter(ctx context.Context, config config.WriteConfig, logger types.Logger, reporter types.Reportable, manifest types.Manifestable, guard types.Guardable, observer types.Observable, tracer types.Traceable) (*archiveWriter, error) {
+	// The archive may be written to any directory, so only its own is swept.
+	dir := filepath.Dir(config.Archive.FilePath)
+	sweepTempFiles(dir, false, logger)
+
+	file, err := os.CreateTemp(dir, tempPrefix+filepath.Base(config.Archive.FilePath)+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("caught err while creating archive: %w", err)
+	}
+
+	writer := &archiveWriter{
+		ctx:       ctx,
+		config:    config,
+		logger:    logger,
+		reporter:  reporter,
+		manifest:  manifest,
+		guard:     guard,
+		observer:  observer,
+		tracer:    tracer,
+		file:      file,
+		archive:   newArchive(config.Archive.Format, file),
+		names:     make(map[string]struct{}),
+		writeChan: make(chan *types.Download, QueueSize(config)),
+		done:      make(chan struct{}),
+	}
+
+	go func() {
+		writer.writer()
+		close(writer.done)
+	}()
+
+	writer.logger.Infof("Archive writer started: %s", config.Archive.FilePath)
+	return writer, nil
+}
+
+func newArchive(format string, w io.Writer) archive {
+	switch format {
+	case "zip":
+		return &zipArchive{writer: zip.NewWriter(w)}
+	case "tar.gz":
+		gz := gzip.NewWriter(w)
+		return &tarArchive{writer: tar.NewWriter(gz), gzip: gz}
+	}
+	return &tarArchive{writer: tar.NewWriter(w)}
+}
+
+// writer listens for downloads on the writeChan and appends them to the archive.
+func (w *archiveWriter) writer() {
+	defer w.logger.Infof("Archive writer stopped\n")
+
+	for {
+		select {
+		case download, ok := <-w.writeChan:
+			if !ok {
+				return
+			}
+			job := StartWrite(w.tracer, download)
+			start := time.Now()
+			err := w.write(download)
+			w.observer.ObserveWrite(time.Since(start))
+			EndWrite(download, job, err)
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+// write appends the download to the archive, records it in the manifest and reports the outcome. A
+// failed write is counted by its error class and returned.
+func (w *archiveWriter) write(download *types.Download) error {
+	w.stats.writing.Add(1)
+	defer w.stats.writing.Add(-1)
+
+	logger := w.logger.With(download.LogFields()...)
+	err := w.add(download, logger)
+	if err != nil {
+		logger.Errorf("Failed to add to archive: %s", err)
+		w.stats.writeFailed.Add(1)
+		w.failures.Add(err)
+		types.FailSpan(download.Context(), err)
+	} else {
+		download.Written()
+	}
+	ReportWrite(w.reporter, download, err)
+	return err
+}
+
+// add appends the content of the download to the archive and records it in the manifest.
+func (w *archiveWriter) add(download *types.Download, logger types.Logger) error {
+	name := FileName(w.config.Naming, download, download.Content)
+	dir, err := Layout(w.config, download, name, time.Now())
+	if err != nil {
+		return failure.Classify(failure.ClassWrite, err)
+	}
+	name = path.Join(dir, name)
+	// Content addressed names repeat for identical content, which only has to be stored once.
+	if _, ok := w.names[name]; !ok {
+		if err := w.guard.Reserve(int64(len(download.Content))); err != nil {
+			return failure.Classify(failure.ClassDiskSpace, fmt.Errorf("caught err while reserving space: %w", err))
+		}
+		start := time.Now()
+		if err := w.archive.add(name, download.Content, time.Now()); err != nil {
+			w.guard.Release(int64(len(download.Content)))
+			return failure.Classify(failure.ClassWrite, fmt.Errorf("caught err while adding to archive: %w", err))
+		}
+		written := time.Since(start)
+		w.phases.Observe(latency.HostOf(download.URL), latency.PhaseWrite, written)
+		w.observer.ObservePhase(latency.PhaseWrite, written)
+		w.names[name] = struct{}{}
+		w.stats.bytesWritten.Add(int64(len(download.Content)))
+	}
+
+	logger.Debugf("Archived: %s", name)
+	w.stats.writeSuccess.Add(1)
+
+	entry := manifest.NewEntry(download, name)
+	w.entries = append(w.entries, entry)
+	if err := w.manifest.Append(entry); err != nil {
+		logger.Errorf("Failed to add %s to manifest: %s", name, err)
+	}
+	return nil
+}
+
+// PushForWrite sends the download to the writeChan for appending to the archive.
+func (w *archiveWriter) PushForWrite(download *types.Download) {
+	w.writeChan <- download
+}
+
+// Close waits until the queued downloads are archived, then embeds the manifest and finishes the archive.
+func (w *archiveWriter) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.writeChan)
+	})
+	<-w.done
+
+	var content bytes.Buffer
+	encoder := json.NewEncoder(&content)
+	for _, entry := range w.entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("caught err while encoding archive manifest: %w", err)
+		}
+	}
+
+	if err := w.archive.add(config.ManifestFileName, content.Bytes(), time.Now()); err != nil {
+		w.file.Close()
+		return fmt.Errorf("caught err while adding manifest to archive: %w", err)
+	}
+	if err := w.archive.close(); err != nil {
+		w.file.Close()
+		return fmt.Errorf("caught err while finishing archive: %w", err)
+	}
+	if err := w.file.Sync(); err != nil {
+		w.file.Close()
+		return err
+	}
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(w.file.Name(), 0644); err != nil {
+		return fmt.Errorf("caught err while setting archive mode: %w", err)
+	}
+	return renameAtomic(w.file.Name(), w.config.Archive.FilePath, w.config.Fsync)
+}
+
+func (w *archiveWriter) GetStats() types.WriterStats {
+	return types.WriterStats{
+		Workers:       1,
+		QueueDepth:    len(w.writeChan),
+		WriteFailed:   w.stats.writeFailed.Load(),
+		Writing:       w.stats.writing.Load(),
+		WriteSuccess:  w.stats.writeSuccess.Load(),
+		BytesWritten:  w.stats.bytesWritten.Load(),
+		FailedReasons: w.failures.Get(),
+		Phases:        w.phases.Stats(),
+	}
+}
+
+// GetHostPhases returns the write phase per host.
+func (w *archiveWriter) GetHostPhases() map[string]map[string]types.PhaseStats {
+	return w.phases.HostStats()
+}
+
+type tarArchive struct {
+	writer *tar.Writer
+	gzip   *gzip.Writer
+}
+
+func (a *tarArchive) add(name string, content []byte, modTime time.Time) error {
+	header := &tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(content)),
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	}
+	if err := a.writer.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := a.writer.Write(content)
+	return err
+}
+
+func (a *tarArchive) close() error {
+	if err := a.writer.Close(); err != nil {
+		return err
+	}
+	if a.gzip != nil {
+		return a.gzip.Close()
+	}
+	return nil
+}
+
+type zipArchive struct {
+	writer *zip.Writer
+}
+
+func (a *zipArchive) add(name string, content []byte, modTime time.Time) error {
+	fw, err := a.writer.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modTime,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(content)
+	return err
+}
+
+func (a *zipArchive) close() error {
+	return a.writer.Close()
+}
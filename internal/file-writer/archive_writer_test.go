@@ -0,0 +1,118 @@
+package filewriter
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// readArchive returns the content of every entry in the archive at path, keyed by name.
+func readArchive(t *testing.T, format, path string) map[string][]byte {
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	entries := make(map[string][]byte)
+	if format == "zip" {
+		reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		assert.NoError(t, err)
+		for _, file := range reader.File {
+			rc, err := file.Open()
+			assert.NoError(t, err)
+			entries[file.Name], err = io.ReadAll(rc)
+			assert.NoError(t, err)
+			rc.Close()
+		}
+		return entries
+	}
+
+	var r io.Reader = bytes.NewReader(content)
+	if format == "tar.gz" {
+		r, err = gzip.NewReader(r)
+		assert.NoError(t, err)
+	}
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return entries
+		}
+		assert.NoError(t, err)
+		entries[header.Name], err = io.ReadAll(reader)
+		assert.NoError(t, err)
+	}
+}
+
+func TestArchiveWriter(t *testing.T) {
+	for _, format := range []string{"tar", "tar.gz", "zip"} {
+		t.Run(format, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), "downloads."+format)
+			cfg := config.WriteConfig{
+				Naming:  config.NamingSHA256,
+				Archive: config.ArchiveConfig{Format: format, FilePath: archivePath},
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			writer, err := NewArchiveWriter(ctx, cfg, types.NewLoggerStub(), types.NewReporterStub(), types.NewManifestStub(), types.NewGuardStub(), types.NewObserverStub(), types.NewTracerStub())
+			assert.NoError(t, err)
+			assert.Equal(t, DefaultQueueSize, cap(writer.writeChan))
+
+			writer.PushForWrite(&types.Download{URL: "https://example.com/a", Content: []byte("test data")})
+			writer.PushForWrite(&types.Download{URL: "https://example.com/b", Content: []byte("other data")})
+			writer.PushForWrite(&types.Download{URL: "https://example.com/c", Content: []byte("test data")})
+			assert.NoError(t, writer.Close())
+
+			entries := readArchive(t, format, archivePath)
+			assert.Len(t, entries, 3)
+			assert.Equal(t, []byte("test data"), entries["916f0027a575074ce72a331777c3478d6513f786a591bd892da1a577bf2335f9.txt"])
+
+			var manifestEntries []types.ManifestEntry
+			scanner := bufio.NewScanner(bytes.NewReader(entries[config.ManifestFileName]))
+			for scanner.Scan() {
+				var entry types.ManifestEntry
+				assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+				manifestEntries = append(manifestEntries, entry)
+			}
+			assert.Len(t, manifestEntries, 3)
+			assert.Equal(t, "https://example.com/c", manifestEntries[2].URL)
+			assert.Equal(t, manifestEntries[0].File, manifestEntries[2].File)
+
+			assert.Equal(t, int32(3), writer.stats.writeSuccess.Load())
+			assert.Equal(t, int64(len("test data")+len("other data")), writer.stats.bytesWritten.Load())
+		})
+	}
+}
+
+func TestNewArchiveWriter_QueueSize(t *testing.T) {
+	cfg := config.WriteConfig{
+		QueueSize: 1,
+		Archive:   config.ArchiveConfig{Format: "tar", FilePath: filepath.Join(t.TempDir(), "downloads.tar")},
+	}
+
+	writer, err := NewArchiveWriter(context.Background(), cfg, types.NewLoggerStub(), types.NewReporterStub(), types.NewManifestStub(), types.NewGuardStub(), types.NewObserverStub(), types.NewTracerStub())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, cap(writer.writeChan))
+	assert.NoError(t, writer.Close())
+}
+
+func TestNewArchiveWriterError(t *testing.T) {
+	cfg := config.WriteConfig{
+		Archive: config.ArchiveConfig{Format: "tar", FilePath: filepath.Join(t.TempDir(), "missing", "downloads.tar")},
+	}
+
+	writer, err := NewArchiveWriter(context.Background(), cfg, types.NewLoggerStub(), types.NewReporterStub(), types.NewManifestStub(), types.NewGuardStub(), types.NewObserverStub(), types.NewTracerStub())
+	assert.Error(t, err)
+	assert.Nil(t, writer)
+}
@@ -0,0 +1,114 @@
+package filewriter
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+// tempPrefix marks files and directories that are still being written.
+const tempPrefix = ".tmp-"
+
+// writeTemp writes content to a new temp file next to filePath and returns its path. Renaming it to
+// filePath afterwards means filePath either does not exist or is complete, as both are in the same
+// directory and so on the same filesystem. With fsync the content is flushed to disk before
+// returning, and the time that took is returned as well.
+func writeTemp(filePath string, content []byte, fsync bool) (string, time.Duration, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), tempPrefix+filepath.Base(filePath)+"-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("caught err while creating temp file: %w", err)
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", 0, fmt.Errorf("caught err while writing temp file: %w", err)
+	}
+	var synced time.Duration
+	if fsync {
+		start := time.Now()
+		if err := tmp.Sync(); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return "", 0, fmt.Errorf("caught err while syncing temp file: %w", err)
+		}
+		synced = time.Since(start)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", 0, fmt.Errorf("caught err while closing temp file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return "", 0, fmt.Errorf("caught err while setting file mode: %w", err)
+	}
+	return tmp.Name(), synced, nil
+}
+
+// renameAtomic moves a finished temp file or directory into place, syncing the parent directory
+// with fsync so that the rename survives a crash.
+func renameAtomic(tmpPath, filePath string, fsync bool) error {
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		return fmt.Errorf("caught err while renaming temp file: %w", err)
+	}
+	if !fsync {
+		return nil
+	}
+	return syncDir(filepath.Dir(filePath))
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("caught err while opening directory for sync: %w", err)
+	}
+	defer d.Close()
+
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("caught err while syncing directory: %w", err)
+	}
+	return nil
+}
+
+// sweepTempFiles removes the temp files and directories a previous run left behind when it crashed.
+// With recursive the whole tree below dir is swept, as temp files are written next to their final
+// path, otherwise only dir itself.
+func sweepTempFiles(dir string, recursive bool, logger types.Logger) {
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if filePath == dir && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+			logger.Errorf("Failed to sweep temp files in %s: %s\n", filePath, err)
+			return nil
+		}
+		if filePath == dir {
+			return nil
+		}
+		if !strings.HasPrefix(entry.Name(), tempPrefix) {
+			if entry.IsDir() && !recursive {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if err := os.RemoveAll(filePath); err != nil {
+			logger.Errorf("Failed to remove stale temp file %s: %s\n", filePath, err)
+		} else {
+			logger.Infof("Removed stale temp file: %s", filePath)
+		}
+		if entry.IsDir() {
+			return fs.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf("Failed to sweep temp files in %s: %s\n", dir, err)
+	}
+}
@@ -0,0 +1,77 @@
+package filewriter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteTemp(t *testing.T) {
+	for _, fsync := range []bool{false, true} {
+		tempDir := t.TempDir()
+		filePath := filepath.Join(tempDir, "a.txt")
+
+		tmpPath, _, err := writeTemp(filePath, []byte("test data"), fsync)
+		assert.NoError(t, err)
+		assert.Equal(t, tempDir, filepath.Dir(tmpPath))
+		assert.True(t, strings.HasPrefix(filepath.Base(tmpPath), tempPrefix+"a.txt-"))
+		assert.NoFileExists(t, filePath)
+
+		assert.NoError(t, renameAtomic(tmpPath, filePath, fsync))
+
+		content, err := os.ReadFile(filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, "test data", string(content))
+
+		info, err := os.Stat(filePath)
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+
+		// No temp file is left behind next to the final one.
+		files, err := os.ReadDir(tempDir)
+		assert.NoError(t, err)
+		assert.Len(t, files, 1)
+	}
+}
+
+func TestWriteTempError(t *testing.T) {
+	_, _, err := writeTemp(filepath.Join(t.TempDir(), "missing", "a.txt"), []byte("test data"), false)
+	assert.Error(t, err)
+}
+
+func TestSweepTempFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, "a.txt"), []byte("a"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(tempDir, tempPrefix+"b.txt-123"), []byte("b"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(tempDir, tempPrefix+"c", "d"), 0755))
+	// Temp files of layout directories, and directories moved aside while replacing one.
+	nested := filepath.Join(tempDir, "example.com", "2026")
+	assert.NoError(t, os.MkdirAll(filepath.Join(nested, tempPrefix+"e-old", "f"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(nested, tempPrefix+"g.txt-456"), []byte("g"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(nested, "h.txt"), []byte("h"), 0644))
+
+	// Without recursive, only the top level is swept.
+	sweepTempFiles(tempDir, false, types.NewLoggerStub())
+	assert.NoFileExists(t, filepath.Join(tempDir, tempPrefix+"b.txt-123"))
+	assert.FileExists(t, filepath.Join(nested, tempPrefix+"g.txt-456"))
+
+	sweepTempFiles(tempDir, true, types.NewLoggerStub())
+
+	files, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+	assert.Equal(t, "a.txt", files[0].Name())
+	assert.Equal(t, "example.com", files[1].Name())
+
+	files, err = os.ReadDir(nested)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "h.txt", files[0].Name())
+
+	// A missing directory has nothing to sweep.
+	sweepTempFiles(filepath.Join(tempDir, "missing"), true, types.NewLoggerStub())
+}
@@ -0,0 +1,177 @@
+package filewriter
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+const (
+	DefaultMaxDecodedSize    = 1 << 30
+	DefaultMaxExtractedFiles = 10_000
+)
+
+var ErrDecodedTooLarge = failure.New(failure.ClassTooLarge, "decoded content exceeds maximum size")
+
+type format int
+
+const (
+	formatPlain format = iota
+	formatGzip
+	formatZstd
+	formatZip
+	formatTar
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic  = []byte("PK\x03\x04")
+	tarMagic  = []byte("ustar")
+)
+
+// detectFormat identifies compressed and archive payloads by their magic bytes.
+func detectFormat(content []byte) format {
+	switch {
+	case bytes.HasPrefix(content, gzipMagic):
+		return formatGzip
+	case bytes.HasPrefix(content, zstdMagic):
+		return formatZstd
+	case bytes.HasPrefix(content, zipMagic):
+		return formatZip
+	case len(content) >= 262 && bytes.Equal(content[257:262], tarMagic):
+		return formatTar
+	}
+	return formatPlain
+}
+
+// decode applies the configured decoding steps and returns the content to write.
+func (w *fileWriter) decode(download *types.Download) ([]byte, error) {
+	content := download.Content
+
+	if w.config.Decode.ContentEncoding && download.Header != nil {
+		decoded, err := decodeContentEncoding(download.Header.Get("Content-Encoding"), content, w.maxDecodedSize())
+		if err != nil {
+			return nil, err
+		}
+		if len(decoded) != len(content) || !bytes.Equal(decoded, content) {
+			w.stats.decoded.Add(1)
+		}
+		content = decoded
+	}
+
+	if !w.config.Decode.Decompress && !w.config.Decode.Extract {
+		return content, nil
+	}
+
+	f := detectFormat(content)
+	if f != formatGzip && f != formatZstd {
+		return content, nil
+	}
+
+	decompressed, err := decompress(f, content, w.maxDecodedSize())
+	if err != nil {
+		return nil, err
+	}
+
+	// Without Decompress a compressed payload is only unwrapped when it turns out to be a tarball.
+	if !w.config.Decode.Decompress && detectFormat(decompressed) != formatTar {
+		return content, nil
+	}
+	w.stats.decompressed.Add(1)
+	return decompressed, nil
+}
+
+// decodeContentEncoding reverses the codings listed in a Content-Encoding header, last one first.
+func decodeContentEncoding(header string, content []byte, maxSize int64) ([]byte, error) {
+	if header == "" {
+		return content, nil
+	}
+
+	codings := strings.Split(header, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		var reader io.Reader
+		switch coding := strings.ToLower(strings.TrimSpace(codings[i])); coding {
+		case "identity", "":
+			continue
+		case "gzip", "x-gzip":
+			gz, err := gzip.NewReader(bytes.NewReader(content))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode gzip content encoding: %w", err)
+			}
+			reader = gz
+		case "deflate":
+			reader = flate.NewReader(bytes.NewReader(content))
+		case "zstd":
+			zr, err := zstd.NewReader(bytes.NewReader(content))
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode zstd content encoding: %w", err)
+			}
+			defer zr.Close()
+			reader = zr
+		default:
+			return nil, fmt.Errorf("unsupported content encoding: %s", coding)
+		}
+
+		decoded, err := readLimited(reader, maxSize)
+		if err != nil {
+			return nil, err
+		}
+		content = decoded
+	}
+
+	return content, nil
+}
+
+// decompress unwraps a single gzip or zstd stream.
+func decompress(f format, content []byte, maxSize int64) ([]byte, error) {
+	switch f {
+	case formatGzip:
+		gz, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress gzip: %w", err)
+		}
+		return readLimited(gz, maxSize)
+	case formatZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress zstd: %w", err)
+		}
+		defer zr.Close()
+		return readLimited(zr, maxSize)
+	}
+	return content, nil
+}
+
+// readLimited reads r to the end, failing once more than maxSize bytes come out of it.
+func readLimited(r io.Reader, maxSize int64) ([]byte, error) {
+	content, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode content: %w", err)
+	}
+	if int64(len(content)) > maxSize {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrDecodedTooLarge, maxSize)
+	}
+	return content, nil
+}
+
+func (w *fileWriter) maxDecodedSize() int64 {
+	if w.config.Decode.MaxSize > 0 {
+		return w.config.Decode.MaxSize
+	}
+	return DefaultMaxDecodedSize
+}
+
+func (w *fileWriter) maxExtractedFiles() int {
+	if w.config.Decode.MaxFiles > 0 {
+		return w.config.Decode.MaxFiles
+	}
+	return DefaultMaxExtractedFiles
+}
@@ -0,0 +1,131 @@
+package filewriter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func gzipped(t *testing.T, content []byte) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(content)
+	assert.NoError(t, err)
+	assert.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func zstded(t *testing.T, content []byte) []byte {
+	zw, err := zstd.NewWriter(nil)
+	assert.NoError(t, err)
+	defer zw.Close()
+	return zw.EncodeAll(content, nil)
+}
+
+func newDecodeTestWriter(t *testing.T, decode config.DecodeConfig) *fileWriter {
+	return &fileWriter{
+		config:   config.WriteConfig{WriteDir: t.TempDir(), Decode: decode},
+		logger:   types.NewLoggerStub(),
+		reporter: types.NewReporterStub(),
+		manifest: types.NewManifestStub(),
+		guard:    types.NewGuardStub(),
+		observer: types.NewObserverStub(),
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	data := []byte("test data")
+
+	assert.Equal(t, formatPlain, detectFormat(data))
+	assert.Equal(t, formatPlain, detectFormat(nil))
+	assert.Equal(t, formatGzip, detectFormat(gzipped(t, data)))
+	assert.Equal(t, formatZstd, detectFormat(zstded(t, data)))
+	assert.Equal(t, formatZip, detectFormat(zipOf(t, map[string]string{"a.txt": "a"})))
+	assert.Equal(t, formatTar, detectFormat(tarOf(t, map[string]string{"a.txt": "a"})))
+}
+
+func TestDecodeContentEncoding(t *testing.T) {
+	data := []byte("test data")
+
+	decoded, err := decodeContentEncoding("gzip", gzipped(t, data), 100)
+	assert.NoError(t, err)
+	assert.Equal(t, data, decoded)
+
+	// Codings are undone in reverse order of application.
+	decoded, err = decodeContentEncoding("gzip, zstd", zstded(t, gzipped(t, data)), 100)
+	assert.NoError(t, err)
+	assert.Equal(t, data, decoded)
+
+	decoded, err = decodeContentEncoding("identity", data, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, data, decoded)
+
+	_, err = decodeContentEncoding("br", data, 100)
+	assert.ErrorContains(t, err, "unsupported content encoding")
+
+	_, err = decodeContentEncoding("gzip", gzipped(t, bytes.Repeat(data, 100)), 100)
+	assert.ErrorIs(t, err, ErrDecodedTooLarge)
+}
+
+func TestDecode(t *testing.T) {
+	data := []byte("test data")
+	download := &types.Download{
+		Header:  http.Header{"Content-Encoding": []string{"gzip"}},
+		Content: gzipped(t, gzipped(t, data)),
+	}
+
+	// Without options the content is written as downloaded.
+	w := newDecodeTestWriter(t, config.DecodeConfig{})
+	content, err := w.decode(download)
+	assert.NoError(t, err)
+	assert.Equal(t, download.Content, content)
+
+	w = newDecodeTestWriter(t, config.DecodeConfig{ContentEncoding: true})
+	content, err = w.decode(download)
+	assert.NoError(t, err)
+	assert.Equal(t, gzipped(t, data), content)
+	assert.Equal(t, int32(1), w.stats.decoded.Load())
+
+	w = newDecodeTestWriter(t, config.DecodeConfig{ContentEncoding: true, Decompress: true})
+	content, err = w.decode(download)
+	assert.NoError(t, err)
+	assert.Equal(t, data, content)
+	assert.Equal(t, int32(1), w.stats.decompressed.Load())
+
+	// Extract alone only unwraps compressed tarballs.
+	w = newDecodeTestWriter(t, config.DecodeConfig{Extract: true})
+	content, err = w.decode(&types.Download{Content: gzipped(t, data)})
+	assert.NoError(t, err)
+	assert.Equal(t, gzipped(t, data), content)
+
+	archive := tarOf(t, map[string]string{"a.txt": "a"})
+	content, err = w.decode(&types.Download{Content: zstded(t, archive)})
+	assert.NoError(t, err)
+	assert.Equal(t, archive, content)
+}
+
+func TestWrite_Decompress(t *testing.T) {
+	data := []byte("test data")
+	w := newDecodeTestWriter(t, config.DecodeConfig{Decompress: true, MaxSize: 100})
+
+	w.write(&types.Download{URL: "https://example.com/a.gz", Content: gzipped(t, data)})
+	w.write(&types.Download{URL: "https://example.com/bomb.gz", Content: gzipped(t, bytes.Repeat(data, 100))})
+
+	files, err := os.ReadDir(w.config.WriteDir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	content, err := os.ReadFile(filepath.Join(w.config.WriteDir, files[0].Name()))
+	assert.NoError(t, err)
+	assert.Equal(t, data, content)
+	assert.Equal(t, int32(1), w.stats.writeSuccess.Load())
+	assert.Equal(t, int32(1), w.stats.writeFailed.Load())
+}
@@ -0,0 +1,166 @@
+package filewriter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+// ExistingFileField is the CSV column overriding the existing file policy for a single row.
+const ExistingFileField = "existing_file"
+
+// maxRenameSuffix bounds the search for a free name with the rename policy.
+const maxRenameSuffix = 10_000
+
+var ErrNoFreeName = failure.New(failure.ClassWrite, "no free file name left")
+
+// existingPolicy returns the policy of the download's row if it names a valid one, or the configured policy.
+func (w *fileWriter) existingPolicy(download *types.Download) string {
+	if policy, ok := download.Fields[ExistingFileField]; ok && policy != "" {
+		switch policy = strings.ToLower(policy); policy {
+		case config.ExistingFileOverwrite, config.ExistingFileSkip, config.ExistingFileRename, config.ExistingFileChecksum:
+			return policy
+		}
+		w.logger.With(download.LogFields()...).Warnf("Ignoring unknown %s %q", ExistingFileField, policy)
+	}
+	if w.config.ExistingFile != "" {
+		return w.config.ExistingFile
+	}
+	return config.ExistingFileOverwrite
+}
+
+// place moves the finished temp file or directory at tmpPath to filePath following the existing file
+// policy of the download, after the sidecar temp file at metaPath unless it is empty. It returns
+// the path the content ended up at, or "" when nothing was moved because the existing file is kept.
+func (w *fileWriter) place(download *types.Download, tmpPath, metaPath, filePath string, content []byte) (string, error) {
+	// Checking for an existing file and renaming over it has to happen in one step across all workers.
+	w.placeLock.Lock()
+	defer w.placeLock.Unlock()
+
+	finalPath, replace, err := w.target(download, filePath, content)
+	if err != nil || finalPath == "" {
+		return "", err
+	}
+
+	if metaPath == "" {
+		return finalPath, w.moveContent(tmpPath, finalPath, replace)
+	}
+
+	// The sidecar goes first, so that a failure leaves any existing content untouched. The sidecar of
+	// an existing file is moved aside meanwhile and put back when the content fails to follow.
+	metaFinal := finalPath + SidecarExt
+	backup := filepath.Join(filepath.Dir(metaFinal), tempPrefix+filepath.Base(metaFinal)+"-old")
+	if err := os.Rename(metaFinal, backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("caught err while moving existing sidecar aside: %w", err)
+	}
+	restore := func() {
+		os.Remove(metaFinal)
+		os.Rename(backup, metaFinal)
+	}
+
+	if err := renameAtomic(metaPath, metaFinal, w.config.Fsync); err != nil {
+		restore()
+		return "", fmt.Errorf("caught err while placing sidecar: %w", err)
+	}
+	if err := w.moveContent(tmpPath, finalPath, replace); err != nil {
+		restore()
+		return "", err
+	}
+	os.Remove(backup)
+	w.stats.sidecars.Add(1)
+	return finalPath, nil
+}
+
+// target picks the path the content goes to following the existing file policy, holding the
+// placeLock. It returns "" when the existing file is kept, and whether an existing directory has to
+// be replaced.
+func (w *fileWriter) target(download *types.Download, filePath string, content []byte) (string, bool, error) {
+	info, err := os.Lstat(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return filePath, false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("caught err while checking for existing file: %w", err)
+	}
+
+	switch w.existingPolicy(download) {
+	case config.ExistingFileSkip:
+		w.stats.existingSkipped.Add(1)
+		return "", false, nil
+	case config.ExistingFileRename:
+		freePath, err := freeName(filePath)
+		if err != nil {
+			return "", false, err
+		}
+		w.stats.existingRenamed.Add(1)
+		return freePath, false, nil
+	case config.ExistingFileChecksum:
+		// Extracted directories have no single checksum and are replaced like with overwrite.
+		if !info.IsDir() && sameContent(filePath, content) {
+			w.stats.existingKept.Add(1)
+			return "", false, nil
+		}
+	}
+
+	w.stats.existingOverwritten.Add(1)
+	return filePath, info.IsDir(), nil
+}
+
+// moveContent renames the temp file or directory at tmpPath to filePath, replacing the directory
+// there if replace is set.
+func (w *fileWriter) moveContent(tmpPath, filePath string, replace bool) error {
+	if replace {
+		return replaceDir(tmpPath, filePath, w.config.Fsync)
+	}
+	return renameAtomic(tmpPath, filePath, w.config.Fsync)
+}
+
+// freeName returns the first of name-1.ext, name-2.ext, ... that does not exist yet.
+func freeName(filePath string) (string, error) {
+	ext := filepath.Ext(filePath)
+	base := strings.TrimSuffix(filePath, ext)
+	for i := 1; i <= maxRenameSuffix; i++ {
+		candidate := fmt.Sprintf("%s-%d%s", base, i, ext)
+		if _, err := os.Lstat(candidate); errors.Is(err, os.ErrNotExist) {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrNoFreeName, filePath)
+}
+
+// sameContent reports whether the file at filePath holds exactly content.
+func sameContent(filePath string, content []byte) bool {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return false
+	}
+	sum := sha256.Sum256(content)
+	return bytes.Equal(hash.Sum(nil), sum[:])
+}
+
+// replaceDir swaps the directory at dirPath for the one at tmpPath. Renaming over a non-empty
+// directory fails, so the old one is moved aside first and removed afterwards.
+func replaceDir(tmpPath, dirPath string, fsync bool) error {
+	oldPath := filepath.Join(filepath.Dir(dirPath), tempPrefix+filepath.Base(dirPath)+"-old")
+	if err := os.Rename(dirPath, oldPath); err != nil {
+		return fmt.Errorf("caught err while moving existing directory aside: %w", err)
+	}
+	defer os.RemoveAll(oldPath)
+
+	return renameAtomic(tmpPath, dirPath, fsync)
+}
@@ -0,0 +1,158 @@
+package filewriter
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	typeMocks "github.com/puruabhi/jfrog/home-assignment/internal/types/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func newExistingTestWriter(t *testing.T, policy string) *fileWriter {
+	return &fileWriter{
+		config:   config.WriteConfig{WriteDir: t.TempDir(), Naming: config.NamingURL, ExistingFile: policy},
+		logger:   types.NewLoggerStub(),
+		reporter: types.NewReporterStub(),
+		manifest: types.NewManifestStub(),
+		guard:    types.NewGuardStub(),
+		observer: types.NewObserverStub(),
+	}
+}
+
+// dirContent returns the content of every file in dir keyed by name.
+func dirContent(t *testing.T, dir string) map[string]string {
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+
+	content := make(map[string]string)
+	for _, file := range files {
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		assert.NoError(t, err)
+		content[file.Name()] = string(data)
+	}
+	return content
+}
+
+func values(m map[string]string) []string {
+	vals := make([]string, 0, len(m))
+	for _, v := range m {
+		vals = append(vals, v)
+	}
+	sort.Strings(vals)
+	return vals
+}
+
+func TestWrite_ExistingFile(t *testing.T) {
+	first := &types.Download{URL: "https://example.com/a.txt", Content: []byte("first")}
+	second := &types.Download{URL: "https://example.com/a.txt", Content: []byte("second")}
+
+	tests := []struct {
+		policy   string
+		expected []string
+		check    func(t *testing.T, w *fileWriter)
+	}{
+		{"", []string{"second"}, func(t *testing.T, w *fileWriter) {
+			assert.Equal(t, int32(1), w.stats.existingOverwritten.Load())
+		}},
+		{config.ExistingFileSkip, []string{"first"}, func(t *testing.T, w *fileWriter) {
+			assert.Equal(t, int32(1), w.stats.existingSkipped.Load())
+		}},
+		{config.ExistingFileRename, []string{"first", "second"}, func(t *testing.T, w *fileWriter) {
+			assert.Equal(t, int32(1), w.stats.existingRenamed.Load())
+		}},
+		{config.ExistingFileChecksum, []string{"second"}, func(t *testing.T, w *fileWriter) {
+			assert.Equal(t, int32(1), w.stats.existingOverwritten.Load())
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			w := newExistingTestWriter(t, tt.policy)
+			w.write(first)
+			w.write(second)
+
+			assert.Equal(t, tt.expected, values(dirContent(t, w.config.WriteDir)))
+			tt.check(t, w)
+		})
+	}
+}
+
+func TestWrite_ExistingFileChecksumMatch(t *testing.T) {
+	w := newExistingTestWriter(t, config.ExistingFileChecksum)
+	download := &types.Download{URL: "https://example.com/a.txt", Content: []byte("same")}
+
+	w.write(download)
+	w.write(download)
+
+	assert.Len(t, dirContent(t, w.config.WriteDir), 1)
+	assert.Equal(t, int32(1), w.stats.existingKept.Load())
+	assert.Equal(t, int32(1), w.stats.writeSuccess.Load())
+}
+
+func TestWrite_ExistingFileRenameSuffix(t *testing.T) {
+	w := newExistingTestWriter(t, config.ExistingFileRename)
+	for _, data := range []string{"a", "b", "c"} {
+		w.write(&types.Download{URL: "https://example.com/a.txt", Content: []byte(data)})
+	}
+
+	content := dirContent(t, w.config.WriteDir)
+	name := urlName("https://example.com/a.txt")
+	base := name[:len(name)-len(".txt")]
+	assert.Equal(t, map[string]string{name: "a", base + "-1.txt": "b", base + "-2.txt": "c"}, content)
+}
+
+func TestWrite_ExistingFileRowPolicy(t *testing.T) {
+	w := newExistingTestWriter(t, config.ExistingFileOverwrite)
+
+	w.write(&types.Download{URL: "https://example.com/a.txt", Content: []byte("first")})
+	w.write(&types.Download{
+		URL:     "https://example.com/a.txt",
+		Content: []byte("second"),
+		Fields:  map[string]string{ExistingFileField: "Skip"},
+	})
+	assert.Equal(t, []string{"first"}, values(dirContent(t, w.config.WriteDir)))
+
+	// Unknown row policies fall back to the configured one.
+	w.write(&types.Download{
+		URL:     "https://example.com/a.txt",
+		Content: []byte("third"),
+		Fields:  map[string]string{ExistingFileField: "merge"},
+	})
+	assert.Equal(t, []string{"third"}, values(dirContent(t, w.config.WriteDir)))
+}
+
+func TestWrite_ExistingDirectory(t *testing.T) {
+	w := newExistingTestWriter(t, config.ExistingFileOverwrite)
+	w.config.Decode.Extract = true
+
+	w.write(&types.Download{URL: "https://example.com/a.tar", Content: tarOf(t, map[string]string{"a.txt": "first", "old.txt": "old"})})
+	w.write(&types.Download{URL: "https://example.com/a.tar", Content: tarOf(t, map[string]string{"a.txt": "second"})})
+
+	dirs, err := os.ReadDir(w.config.WriteDir)
+	assert.NoError(t, err)
+	assert.Len(t, dirs, 1)
+	assert.Equal(t, map[string]string{"a.txt": "second"}, dirContent(t, filepath.Join(w.config.WriteDir, dirs[0].Name())))
+	assert.Equal(t, int32(1), w.stats.existingOverwritten.Load())
+}
+
+func TestWrite_ExistingFileReleasesSpace(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockGuard := typeMocks.NewMockGuardable(ctrl)
+	w := newExistingTestWriter(t, config.ExistingFileSkip)
+	w.guard = mockGuard
+
+	// The written file keeps its bytes, the skipped one gives them back.
+	mockGuard.EXPECT().Reserve(int64(5)).Return(nil).Times(2)
+	mockGuard.EXPECT().Release(int64(5)).Times(1)
+
+	assert.NoError(t, w.write(&types.Download{URL: "https://example.com/a.txt", Content: []byte("first")}))
+	assert.NoError(t, w.write(&types.Download{URL: "https://example.com/a.txt", Content: []byte("other")}))
+	assert.Equal(t, []string{"first"}, values(dirContent(t, w.config.WriteDir)))
+}
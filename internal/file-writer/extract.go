@@ -0,0 +1,170 @@
+package filewriter
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+)
+
+var (
+	ErrUnsafePath      = failure.New(failure.ClassDecode, "archive entry escapes the extraction directory")
+	ErrTooManyFiles    = failure.New(failure.ClassTooLarge, "archive exceeds maximum number of files")
+	ErrUnsupportedType = failure.New(failure.ClassDecode, "unsupported archive")
+)
+
+// extractor unpacks archives into dir, enforcing the total size and file count limits across all entries.
+type extractor struct {
+	dir      string
+	maxSize  int64
+	maxFiles int
+	// reserve, when set, accounts for every chunk of an entry before it is written, failing the
+	// extraction once the output is out of space.
+	reserve func(size int64) error
+	written int64
+	files   int
+}
+
+// reservingWriter reserves the bytes of every write before passing them on.
+type reservingWriter struct {
+	w       io.Writer
+	reserve func(size int64) error
+}
+
+func (r *reservingWriter) Write(p []byte) (int, error) {
+	if err := r.reserve(int64(len(p))); err != nil {
+		return 0, failure.Classify(failure.ClassDiskSpace, fmt.Errorf("caught err while reserving space: %w", err))
+	}
+	return r.w.Write(p)
+}
+
+// extract unpacks the tar or zip archive in content.
+func (e *extractor) extract(content []byte) error {
+	if err := os.MkdirAll(e.dir, 0755); err != nil {
+		return fmt.Errorf("caught err while creating extraction directory: %w", err)
+	}
+
+	switch detectFormat(content) {
+	case formatTar:
+		return e.extractTar(bytes.NewReader(content))
+	case formatZip:
+		return e.extractZip(content)
+	}
+	return ErrUnsupportedType
+}
+
+func (e *extractor) extractTar(r io.Reader) error {
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("caught err while reading tar: %w", err)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := e.mkdir(header.Name); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := e.writeFile(header.Name, reader); err != nil {
+				return err
+			}
+		default:
+			// Links, devices and the like are never extracted, so nothing can point outside of dir.
+			continue
+		}
+	}
+}
+
+func (e *extractor) extractZip(content []byte) error {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return fmt.Errorf("caught err while reading zip: %w", err)
+	}
+
+	for _, file := range reader.File {
+		mode := file.Mode()
+		switch {
+		case mode.IsDir():
+			if err := e.mkdir(file.Name); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := file.Open()
+			if err != nil {
+				return fmt.Errorf("caught err while reading zip entry %s: %w", file.Name, err)
+			}
+			err = e.writeFile(file.Name, rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *extractor) mkdir(name string) error {
+	target, err := e.target(name)
+	if err != nil {
+		return err
+	}
+	return os.MkdirAll(target, 0755)
+}
+
+// writeFile writes a single entry, counting it against the limits and reserving its space before
+// any byte hits the disk.
+func (e *extractor) writeFile(name string, r io.Reader) error {
+	target, err := e.target(name)
+	if err != nil {
+		return err
+	}
+
+	e.files++
+	if e.files > e.maxFiles {
+		return fmt.Errorf("%w: more than %d", ErrTooManyFiles, e.maxFiles)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("caught err while creating directory for %s: %w", name, err)
+	}
+	file, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("caught err while creating %s: %w", name, err)
+	}
+	defer file.Close()
+
+	var dst io.Writer = file
+	if e.reserve != nil {
+		dst = &reservingWriter{w: file, reserve: e.reserve}
+	}
+	left := e.maxSize - e.written
+	n, err := io.Copy(dst, io.LimitReader(r, left+1))
+	e.written += n
+	if err != nil {
+		return fmt.Errorf("caught err while extracting %s: %w", name, err)
+	}
+	if n > left {
+		return fmt.Errorf("%w: more than %d bytes", ErrDecodedTooLarge, e.maxSize)
+	}
+	return nil
+}
+
+// target resolves an entry name inside dir, rejecting absolute paths and any ".." that leaves it.
+func (e *extractor) target(name string) (string, error) {
+	name = filepath.FromSlash(strings.TrimSuffix(name, "/"))
+	if name == "" || !filepath.IsLocal(name) {
+		return "", fmt.Errorf("%w: %q", ErrUnsafePath, name)
+	}
+	return filepath.Join(e.dir, name), nil
+}
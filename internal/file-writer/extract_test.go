@@ -0,0 +1,172 @@
+package filewriter
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	typeMocks "github.com/puruabhi/jfrog/home-assignment/internal/types/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func sortedNames(files map[string]string) []string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func tarOf(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range sortedNames(files) {
+		assert.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(files[name]))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func zipOf(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range sortedNames(files) {
+		fw, err := zw.Create(name)
+		assert.NoError(t, err)
+		_, err = fw.Write([]byte(files[name]))
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func newTestExtractor(t *testing.T) *extractor {
+	return &extractor{
+		dir:      filepath.Join(t.TempDir(), "out"),
+		maxSize:  100,
+		maxFiles: 3,
+	}
+}
+
+func TestExtract(t *testing.T) {
+	files := map[string]string{"a.txt": "a", "dir/b.txt": "bb"}
+
+	for name, archive := range map[string][]byte{"tar": tarOf(t, files), "zip": zipOf(t, files)} {
+		t.Run(name, func(t *testing.T) {
+			e := newTestExtractor(t)
+			assert.NoError(t, e.extract(archive))
+			assert.Equal(t, 2, e.files)
+			assert.Equal(t, int64(3), e.written)
+
+			for name, data := range files {
+				content, err := os.ReadFile(filepath.Join(e.dir, name))
+				assert.NoError(t, err)
+				assert.Equal(t, data, string(content))
+			}
+		})
+	}
+}
+
+func TestExtract_UnsafePath(t *testing.T) {
+	for _, name := range []string{"../evil.txt", "dir/../../evil.txt", "/etc/evil.txt"} {
+		e := newTestExtractor(t)
+		assert.ErrorIs(t, e.extract(tarOf(t, map[string]string{name: "x"})), ErrUnsafePath, name)
+		assert.ErrorIs(t, e.extract(zipOf(t, map[string]string{name: "x"})), ErrUnsafePath, name)
+		assert.NoFileExists(t, filepath.Join(filepath.Dir(e.dir), "evil.txt"))
+	}
+}
+
+func TestExtract_SkipsLinks(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "link", Linkname: "/etc/passwd", Typeflag: tar.TypeSymlink}))
+	assert.NoError(t, tw.Close())
+
+	e := newTestExtractor(t)
+	assert.NoError(t, e.extract(buf.Bytes()))
+	assert.NoFileExists(t, filepath.Join(e.dir, "link"))
+}
+
+func TestExtract_Limits(t *testing.T) {
+	e := newTestExtractor(t)
+	err := e.extract(tarOf(t, map[string]string{"a": "a", "b": "b", "c": "c", "d": "d"}))
+	assert.ErrorIs(t, err, ErrTooManyFiles)
+
+	e = newTestExtractor(t)
+	err = e.extract(zipOf(t, map[string]string{"a": string(bytes.Repeat([]byte("a"), 60)), "b": string(bytes.Repeat([]byte("b"), 60))}))
+	assert.ErrorIs(t, err, ErrDecodedTooLarge)
+}
+
+func TestExtract_Reserve(t *testing.T) {
+	e := newTestExtractor(t)
+	var reserved int64
+	e.reserve = func(size int64) error {
+		if reserved+size > 2 {
+			return errors.New("out of space")
+		}
+		reserved += size
+		return nil
+	}
+
+	err := e.extract(tarOf(t, map[string]string{"a": "a", "b": "bb"}))
+	assert.Error(t, err)
+	assert.Equal(t, failure.ClassDiskSpace, failure.ClassOf(err))
+	assert.Equal(t, int64(1), reserved)
+
+	// The entry that did not fit is never written.
+	content, err := os.ReadFile(filepath.Join(e.dir, "b"))
+	assert.NoError(t, err)
+	assert.Empty(t, content)
+}
+
+func TestWrite_Extract(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockManifest := typeMocks.NewMockManifestable(ctrl)
+	w := &fileWriter{
+		config:   config.WriteConfig{WriteDir: t.TempDir(), Decode: config.DecodeConfig{Extract: true}},
+		logger:   types.NewLoggerStub(),
+		reporter: types.NewReporterStub(),
+		manifest: mockManifest,
+		guard:    types.NewGuardStub(),
+		observer: types.NewObserverStub(),
+	}
+
+	var entry types.ManifestEntry
+	mockManifest.EXPECT().Append(gomock.Any()).DoAndReturn(func(e types.ManifestEntry) error {
+		entry = e
+		return nil
+	}).Times(1)
+
+	archive := gzipped(t, tarOf(t, map[string]string{"a.txt": "a", "dir/b.txt": "bb"}))
+	w.write(&types.Download{URL: "https://example.com/a.tar.gz", Content: archive})
+
+	// A failed extraction leaves nothing behind.
+	w.write(&types.Download{URL: "https://example.com/evil.zip", Content: zipOf(t, map[string]string{"../evil.txt": "x"})})
+
+	dirs, err := os.ReadDir(w.config.WriteDir)
+	assert.NoError(t, err)
+	assert.Len(t, dirs, 1)
+	assert.True(t, dirs[0].IsDir())
+	assert.Equal(t, dirs[0].Name()+"/", entry.File)
+	assert.Equal(t, 3, entry.Size)
+
+	content, err := os.ReadFile(filepath.Join(w.config.WriteDir, dirs[0].Name(), "dir", "b.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "bb", string(content))
+	assert.Equal(t, int32(1), w.stats.extracted.Load())
+	assert.Equal(t, int32(1), w.stats.writeFailed.Load())
+}
@@ -1,14 +1,22 @@
 package filewriter
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/latency"
+	"github.com/puruabhi/jfrog/home-assignment/internal/manifest"
 	"github.com/puruabhi/jfrog/home-assignment/internal/types"
 )
 
@@ -18,85 +26,320 @@ type fileWriter struct {
 	ctx       context.Context
 	config    config.WriteConfig
 	logger    types.Logger
-	writeChan chan []byte
+	reporter  types.Reportable
+	manifest  types.Manifestable
+	guard     types.Guardable
+	observer  types.Observable
+	tracer    types.Traceable
+	writeChan chan *Queued
+	closeOnce sync.Once
+	done      chan struct{}
+	workers   int
+	waits     WaitStats
+	phases    latency.Phases
+	failures  failure.Counts
+	placeLock sync.Mutex
 
 	// stat variables
 	stats struct {
 		writeFailed  atomic.Int32
 		writing      atomic.Int32
 		writeSuccess atomic.Int32
+		bytesWritten atomic.Int64
+		decoded      atomic.Int32
+		decompressed atomic.Int32
+		extracted    atomic.Int32
+		sidecars     atomic.Int32
+
+		existingSkipped     atomic.Int32
+		existingOverwritten atomic.Int32
+		existingRenamed     atomic.Int32
+		existingKept        atomic.Int32
 	}
 }
 
 // NewFileWriter initializes a new fileWriter instance and starts the writer goroutine.
-func NewFileWriter(ctx context.Context, config config.WriteConfig, logger types.Logger) *fileWriter {
+func NewFileWriter(ctx context.Context, config config.WriteConfig, logger types.Logger, reporter types.Reportable, manifest types.Manifestable, guard types.Guardable, observer types.Observable, tracer types.Traceable) *fileWriter {
 	writer := &fileWriter{
 		config:    config,
 		logger:    logger,
+		reporter:  reporter,
+		manifest:  manifest,
+		guard:     guard,
+		observer:  observer,
+		tracer:    tracer,
 		ctx:       ctx,
-		writeChan: make(chan []byte, 100),
+		writeChan: make(chan *Queued, QueueSize(config)),
+		workers:   Concurrency(config),
+		done:      make(chan struct{}),
 	}
 
-	go writer.writer()
+	sweepTempFiles(config.WriteDir, true, logger)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < writer.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			writer.writer()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		writer.logger.Infof("File writer stopped\n")
+		close(writer.done)
+	}()
 
-	writer.logger.Infof("File writer started")
+	writer.logger.Infof("File writer started with %d workers", writer.workers)
 	return writer
 }
 
-// writer listens for bytes on the writeChan and writes them to files.
+// writer listens for downloads on the writeChan and writes them to files. Every worker of the pool runs one.
 func (w *fileWriter) writer() {
-	defer w.logger.Infof("File writer stopped\n")
 	defer w.close()
 
 	for {
 		select {
-		case bytes, ok := <-w.writeChan:
+		case queued, ok := <-w.writeChan:
 			if !ok {
 				return
 			}
-			w.write(bytes)
+			w.observer.ObserveQueueWait(w.waits.Observe(queued))
+			job := StartWrite(w.tracer, queued.Download)
+			start := time.Now()
+			err := w.write(queued.Download)
+			w.observer.ObserveWrite(time.Since(start))
+			EndWrite(queued.Download, job, err)
 		case <-w.ctx.Done():
 			return
 		}
 	}
 }
 
-// write writes the given bytes to a new file.
-func (w *fileWriter) write(bytes []byte) {
+// write writes the downloaded content to a new file, records it in the manifest and reports the
+// outcome. A failed write is counted by its error class and returned.
+func (w *fileWriter) write(download *types.Download) error {
 	w.stats.writing.Add(1)
 	defer w.stats.writing.Add(-1)
 
-	fileName := fmt.Sprintf("%s%s", uuid.New(), fileExt)
-	filePath := path.Join(w.config.WriteDir, fileName)
-
-	if err := os.WriteFile(filePath, bytes, 0644); err != nil {
-		w.logger.Errorf("Failed to save file: %s\n", err)
+	logger := w.logger.With(download.LogFields()...)
+	err := w.save(download, logger)
+	if err != nil {
+		logger.Errorf("Failed to save file: %s", err)
 		w.stats.writeFailed.Add(1)
+		w.failures.Add(err)
+		types.FailSpan(download.Context(), err)
 	} else {
-		w.logger.Debugf("Saved: %s\n", filePath)
-		w.stats.writeSuccess.Add(1)
+		download.Written()
 	}
+	ReportWrite(w.reporter, download, err)
+	return err
 }
 
-// PushForWrite sends bytes to the writeChan for writing to a file.
-func (w *fileWriter) PushForWrite(bytes []byte) {
-	w.writeChan <- bytes
+// save decodes the downloaded content and writes it to a new file, or extracts it when it is an
+// archive to extract.
+func (w *fileWriter) save(download *types.Download, logger types.Logger) error {
+	content, err := w.decode(download)
+	if err != nil {
+		return failure.Classify(failure.ClassDecode, fmt.Errorf("caught err while decoding: %w", err))
+	}
+
+	name := FileName(w.config.Naming, download, content)
+	dir, err := w.prepareDir(download, name)
+	if err != nil {
+		return failure.Classify(failure.ClassWrite, err)
+	}
+
+	if w.config.Decode.Extract {
+		if f := detectFormat(content); f == formatTar || f == formatZip {
+			return w.extract(download, content, path.Join(dir, strings.TrimSuffix(name, path.Ext(name))), logger)
+		}
+	}
+
+	space := &reservation{guard: w.guard}
+	defer space.release()
+	if err := space.reserve(int64(len(content))); err != nil {
+		return failure.Classify(failure.ClassDiskSpace, fmt.Errorf("caught err while reserving space: %w", err))
+	}
+
+	filePath := path.Join(w.config.WriteDir, dir, name)
+	start := time.Now()
+	tmpPath, synced, err := writeTemp(filePath, content, w.config.Fsync)
+	if err != nil {
+		return failure.Classify(failure.ClassWrite, err)
+	}
+	defer os.Remove(tmpPath)
+	w.observePhase(download, latency.PhaseWrite, time.Since(start)-synced)
+	if w.config.Fsync {
+		w.observePhase(download, latency.PhaseFsync, synced)
+	}
+
+	metaPath, err := w.writeSidecar(space, download, content, filePath, int64(len(content)))
+	if err != nil {
+		return failure.Classify(failure.ClassWrite, fmt.Errorf("caught err while writing sidecar: %w", err))
+	}
+	if metaPath != "" {
+		defer os.Remove(metaPath)
+	}
+
+	start = time.Now()
+	finalPath, err := w.place(download, tmpPath, metaPath, filePath, content)
+	if err != nil {
+		return failure.Classify(failure.ClassWrite, err)
+	}
+	w.observePhase(download, latency.PhasePlace, time.Since(start))
+	if finalPath == "" {
+		logger.Debugf("Kept existing file: %s", filePath)
+		return nil
+	}
+
+	space.commit()
+	logger.Debugf("Saved: %s", finalPath)
+	w.stats.writeSuccess.Add(1)
+	w.stats.bytesWritten.Add(int64(len(content)))
+
+	w.appendToManifest(written(download, content), w.relPath(finalPath), len(content))
+	return nil
 }
 
-// close closes the writeChan.
-func (w *fileWriter) close() {
-	close(w.writeChan)
+// extract unpacks an archive into a temp directory and renames it to dirName, relative to the output
+// root, once complete, removing it again when extraction fails.
+func (w *fileWriter) extract(download *types.Download, content []byte, dirName string, logger types.Logger) error {
+	dirPath := path.Join(w.config.WriteDir, dirName)
+	// Space is reserved while the entries are written, so the guard stops an archive that does not fit.
+	space := &reservation{guard: w.guard}
+	defer space.release()
+	e := &extractor{
+		dir:      path.Join(path.Dir(dirPath), tempPrefix+path.Base(dirName)+"-"+uuid.New().String()),
+		maxSize:  w.maxDecodedSize(),
+		maxFiles: w.maxExtractedFiles(),
+		reserve:  space.reserve,
+	}
+	defer os.RemoveAll(e.dir)
+
+	start := time.Now()
+	if err := e.extract(content); err != nil {
+		return failure.Classify(failure.ClassDecode, fmt.Errorf("caught err while extracting: %w", err))
+	}
+	w.observePhase(download, latency.PhaseWrite, time.Since(start))
+	metaPath, err := w.writeSidecar(space, download, content, dirPath, e.written)
+	if err != nil {
+		return failure.Classify(failure.ClassWrite, fmt.Errorf("caught err while writing sidecar: %w", err))
+	}
+	if metaPath != "" {
+		defer os.Remove(metaPath)
+	}
+
+	start = time.Now()
+	finalPath, err := w.place(download, e.dir, metaPath, dirPath, nil)
+	if err != nil {
+		return failure.Classify(failure.ClassWrite, err)
+	}
+	w.observePhase(download, latency.PhasePlace, time.Since(start))
+	if finalPath == "" {
+		logger.Debugf("Kept existing directory: %s", dirPath)
+		return nil
+	}
+
+	space.commit()
+	logger.Debugf("Extracted %d files to: %s", e.files, finalPath)
+	w.stats.writeSuccess.Add(1)
+	w.stats.bytesWritten.Add(e.written)
+	w.stats.extracted.Add(1)
+
+	w.appendToManifest(written(download, content), w.relPath(finalPath)+"/", int(e.written))
+	return nil
+}
+
+// observePhase records how long a write phase of download took.
+func (w *fileWriter) observePhase(download *types.Download, phase string, duration time.Duration) {
+	w.phases.Observe(latency.HostOf(download.URL), phase, duration)
+	w.observer.ObservePhase(phase, duration)
+}
+
+// prepareDir creates the layout directory of the download and returns it relative to the output root.
+func (w *fileWriter) prepareDir(download *types.Download, name string) (string, error) {
+	dir, err := Layout(w.config, download, name, time.Now())
+	if err != nil || dir == "" {
+		return dir, err
+	}
+	if err := os.MkdirAll(path.Join(w.config.WriteDir, dir), 0755); err != nil {
+		return "", fmt.Errorf("caught err while creating directory: %w", err)
+	}
+	return dir, nil
+}
+
+// relPath returns filePath relative to the output root, as recorded in the manifest.
+func (w *fileWriter) relPath(filePath string) string {
+	rel, err := filepath.Rel(w.config.WriteDir, filePath)
+	if err != nil {
+		return filePath
+	}
+	return filepath.ToSlash(rel)
 }
 
-func (w *fileWriter) GetStats() any {
-	type stats struct {
-		WriteFailed  int32 `json:"write_failed"`
-		Writing      int32 `json:"writing"`
-		WriteSuccess int32 `json:"write_success"`
+func (w *fileWriter) appendToManifest(download *types.Download, file string, size int) {
+	entry := manifest.NewEntry(download, file)
+	entry.Size = size
+	if err := w.manifest.Append(entry); err != nil {
+		w.logger.Errorf("Failed to add %s to manifest: %s\n", file, err)
 	}
-	return stats{
-		WriteFailed:  w.stats.writeFailed.Load(),
-		Writing:      w.stats.writing.Load(),
-		WriteSuccess: w.stats.writeSuccess.Load(),
+}
+
+// written returns the download as it was written, so the manifest checksum matches the decoded content.
+func written(download *types.Download, content []byte) *types.Download {
+	if bytes.Equal(content, download.Content) {
+		return download
+	}
+	decoded := *download
+	decoded.Content = content
+	decoded.SHA256 = ""
+	return &decoded
+}
+
+// PushForWrite sends the download to the writeChan for writing to a file.
+func (w *fileWriter) PushForWrite(download *types.Download) {
+	w.writeChan <- NewQueued(download)
+}
+
+// Close stops accepting downloads and waits until the queued ones are written.
+func (w *fileWriter) Close() error {
+	w.close()
+	<-w.done
+	return nil
+}
+
+// close closes the writeChan, once.
+func (w *fileWriter) close() {
+	w.closeOnce.Do(func() {
+		close(w.writeChan)
+	})
+}
+
+func (w *fileWriter) GetStats() types.WriterStats {
+	return types.WriterStats{
+		Workers:             w.workers,
+		QueueDepth:          len(w.writeChan),
+		QueueWaitAvgMs:      w.waits.AvgMs(),
+		QueueWaitMaxMs:      w.waits.MaxMs(),
+		WriteFailed:         w.stats.writeFailed.Load(),
+		Writing:             w.stats.writing.Load(),
+		WriteSuccess:        w.stats.writeSuccess.Load(),
+		BytesWritten:        w.stats.bytesWritten.Load(),
+		Decoded:             w.stats.decoded.Load(),
+		Decompressed:        w.stats.decompressed.Load(),
+		Extracted:           w.stats.extracted.Load(),
+		Sidecars:            w.stats.sidecars.Load(),
+		ExistingSkipped:     w.stats.existingSkipped.Load(),
+		ExistingOverwritten: w.stats.existingOverwritten.Load(),
+		ExistingRenamed:     w.stats.existingRenamed.Load(),
+		ExistingKept:        w.stats.existingKept.Load(),
+		FailedReasons:       w.failures.Get(),
+		Phases:              w.phases.Stats(),
 	}
 }
+
+// GetHostPhases returns the write phases per host.
+func (w *fileWriter) GetHostPhases() map[string]map[string]types.PhaseStats {
+	return w.phases.HostStats()
+}
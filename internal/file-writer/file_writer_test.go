@@ -2,6 +2,7 @@ package filewriter
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -9,7 +10,9 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
 	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	typeMocks "github.com/puruabhi/jfrog/home-assignment/internal/types/mocks"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -24,7 +27,7 @@ func TestNewFileWriter(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	writer := NewFileWriter(ctx, mockConfig, logger)
+	writer := NewFileWriter(ctx, mockConfig, logger, types.NewReporterStub(), types.NewManifestStub(), types.NewGuardStub(), types.NewObserverStub(), types.NewTracerStub())
 	assert.NotNil(t, writer)
 	assert.Equal(t, mockConfig, writer.config)
 	assert.Equal(t, logger, writer.logger)
@@ -42,7 +45,7 @@ func TestWriter(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	writer := NewFileWriter(ctx, mockConfig, logger)
+	writer := NewFileWriter(ctx, mockConfig, logger, types.NewReporterStub(), types.NewManifestStub(), types.NewGuardStub(), types.NewObserverStub(), types.NewTracerStub())
 
 	// Create a temporary directory for testing
 	tempDir := t.TempDir()
@@ -50,7 +53,7 @@ func TestWriter(t *testing.T) {
 
 	// Send some data to the write
 	data := []byte("test data")
-	writer.PushForWrite(data)
+	writer.PushForWrite(&types.Download{URL: "https://example.com/", Content: data})
 
 	// Allow some time for the writer goroutine to process the data
 	time.Sleep(10 * time.Millisecond)
@@ -78,7 +81,7 @@ func TestWrite(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	writer := NewFileWriter(ctx, mockConfig, logger)
+	writer := NewFileWriter(ctx, mockConfig, logger, types.NewReporterStub(), types.NewManifestStub(), types.NewGuardStub(), types.NewObserverStub(), types.NewTracerStub())
 
 	// Create a temporary directory for testing
 	tempDir := t.TempDir()
@@ -86,7 +89,7 @@ func TestWrite(t *testing.T) {
 
 	// Write some data
 	data := []byte("test data")
-	writer.write(data)
+	writer.write(&types.Download{URL: "https://example.com/", Content: data})
 
 	// Check that the file was written
 	files, err := os.ReadDir(tempDir)
@@ -111,7 +114,7 @@ func TestClose(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	writer := NewFileWriter(ctx, mockConfig, logger)
+	writer := NewFileWriter(ctx, mockConfig, logger, types.NewReporterStub(), types.NewManifestStub(), types.NewGuardStub(), types.NewObserverStub(), types.NewTracerStub())
 
 	// Create a channel to signal when the writer goroutine has finished
 	done := make(chan struct{})
@@ -131,3 +134,177 @@ func TestClose(t *testing.T) {
 	_, ok := <-writer.writeChan
 	assert.False(t, ok)
 }
+
+func TestWrite_Manifest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tempDir := t.TempDir()
+	mockConfig := config.WriteConfig{
+		WriteDir: tempDir,
+	}
+	mockManifest := typeMocks.NewMockManifestable(ctrl)
+	logger := types.NewLoggerStub()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	writer := NewFileWriter(ctx, mockConfig, logger, types.NewReporterStub(), mockManifest, types.NewGuardStub(), types.NewObserverStub(), types.NewTracerStub())
+
+	var entry types.ManifestEntry
+	mockManifest.EXPECT().Append(gomock.Any()).DoAndReturn(func(e types.ManifestEntry) error {
+		entry = e
+		return nil
+	}).Times(1)
+
+	data := []byte("test data")
+	writer.write(&types.Download{URL: "https://example.com/", FinalURL: "https://www.example.com/", Content: data})
+
+	files, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	assert.Equal(t, "https://example.com/", entry.URL)
+	assert.Equal(t, "https://www.example.com/", entry.FinalURL)
+	assert.Equal(t, files[0].Name(), entry.File)
+	assert.Equal(t, len(data), entry.Size)
+	assert.Equal(t, "916f0027a575074ce72a331777c3478d6513f786a591bd892da1a577bf2335f9", entry.SHA256)
+}
+
+func TestFileWriterClose(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	writer := NewFileWriter(ctx, config.WriteConfig{WriteDir: tempDir}, types.NewLoggerStub(), types.NewReporterStub(), types.NewManifestStub(), types.NewGuardStub(), types.NewObserverStub(), types.NewTracerStub())
+	for i := 0; i < 10; i++ {
+		writer.PushForWrite(&types.Download{URL: "https://example.com/", Content: []byte("test data")})
+	}
+
+	// Close returns once every queued download is written.
+	assert.NoError(t, writer.Close())
+
+	files, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 10)
+}
+
+func TestFileWriterPool(t *testing.T) {
+	tempDir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mockConfig := config.WriteConfig{WriteDir: tempDir, Concurrency: 8, QueueSize: 1}
+	writer := NewFileWriter(ctx, mockConfig, types.NewLoggerStub(), types.NewReporterStub(), types.NewManifestStub(), types.NewGuardStub(), types.NewObserverStub(), types.NewTracerStub())
+	assert.Equal(t, 1, cap(writer.writeChan))
+
+	for i := 0; i < 50; i++ {
+		writer.PushForWrite(&types.Download{URL: "https://example.com/", Content: []byte("test data")})
+	}
+	assert.NoError(t, writer.Close())
+
+	files, err := os.ReadDir(tempDir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 50)
+	assert.Equal(t, int32(50), writer.stats.writeSuccess.Load())
+	assert.Equal(t, int64(50), writer.waits.count.Load())
+	assert.Equal(t, 8, writer.workers)
+}
+
+func TestWrite_Phases(t *testing.T) {
+	writer := &fileWriter{
+		config:   config.WriteConfig{WriteDir: t.TempDir(), Fsync: true},
+		logger:   types.NewLoggerStub(),
+		reporter: types.NewReporterStub(),
+		manifest: types.NewManifestStub(),
+		guard:    types.NewGuardStub(),
+		observer: types.NewObserverStub(),
+	}
+
+	writer.write(&types.Download{URL: "https://example.com/a", Content: []byte("a")})
+	writer.write(&types.Download{URL: "https://other.com/b", Content: []byte("b")})
+
+	phases := writer.GetStats().Phases
+	assert.Equal(t, int64(2), phases["write"].Count)
+	assert.Equal(t, int64(2), phases["fsync"].Count)
+	assert.Equal(t, int64(2), phases["place"].Count)
+
+	hosts := writer.GetHostPhases()
+	assert.Len(t, hosts, 2)
+	assert.Equal(t, int64(1), hosts["example.com"]["fsync"].Count)
+}
+
+func TestWrite_PhasesWithoutFsync(t *testing.T) {
+	writer := &fileWriter{
+		config:   config.WriteConfig{WriteDir: t.TempDir()},
+		logger:   types.NewLoggerStub(),
+		reporter: types.NewReporterStub(),
+		manifest: types.NewManifestStub(),
+		guard:    types.NewGuardStub(),
+		observer: types.NewObserverStub(),
+	}
+
+	writer.write(&types.Download{URL: "https://example.com/a", Content: []byte("a")})
+
+	phases := writer.GetStats().Phases
+	assert.Equal(t, int64(1), phases["write"].Count)
+	assert.NotContains(t, phases, "fsync")
+}
+
+func TestWrite_Report(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockReporter := typeMocks.NewMockReportable(ctrl)
+	mockGuard := typeMocks.NewMockGuardable(ctrl)
+	dir := t.TempDir()
+	writer := &fileWriter{
+		config:   config.WriteConfig{WriteDir: dir},
+		logger:   types.NewLoggerStub(),
+		reporter: mockReporter,
+		manifest: types.NewManifestStub(),
+		guard:    mockGuard,
+		observer: types.NewObserverStub(),
+	}
+
+	gomock.InOrder(
+		mockGuard.EXPECT().Reserve(int64(1)).Return(nil),
+		mockGuard.EXPECT().Reserve(int64(1)).Return(errors.New("no space left")),
+		mockGuard.EXPECT().Reserve(int64(1)).Return(nil),
+		// The bytes of the failed write are given back.
+		mockGuard.EXPECT().Release(int64(1)),
+	)
+	mockReporter.EXPECT().Record(types.ReportEntry{
+		Row:      1,
+		URL:      "https://example.com/a",
+		FinalURL: "https://example.com/a",
+		Status:   types.ReportStatusDownloaded,
+	})
+	mockReporter.EXPECT().Record(types.ReportEntry{
+		Row:        2,
+		URL:        "https://example.com/b",
+		Status:     types.ReportStatusWriteFailed,
+		Error:      "caught err while reserving space: no space left",
+		ErrorClass: failure.ClassDiskSpace,
+	})
+	mockReporter.EXPECT().Record(gomock.Any()).Do(func(entry types.ReportEntry) {
+		assert.Equal(t, types.ReportStatusWriteFailed, entry.Status)
+		assert.Equal(t, failure.ClassWrite, entry.ErrorClass)
+	})
+
+	// Only downloads that were written are confirmed to the downloader.
+	var written []int
+	onWritten := func(row int) func() {
+		return func() { written = append(written, row) }
+	}
+
+	assert.NoError(t, writer.write(&types.Download{Row: 1, URL: "https://example.com/a", FinalURL: "https://example.com/a", Content: []byte("a"), OnWritten: onWritten(1)}))
+	assert.Error(t, writer.write(&types.Download{Row: 2, URL: "https://example.com/b", Content: []byte("b"), OnWritten: onWritten(2)}))
+	// The output directory is gone, so the write fails.
+	writer.config.WriteDir = filepath.Join(dir, "missing")
+	assert.Error(t, writer.write(&types.Download{Row: 3, URL: "https://example.com/c", Content: []byte("c"), OnWritten: onWritten(3)}))
+	assert.Equal(t, []int{1}, written)
+
+	stats := writer.GetStats()
+	assert.Equal(t, int32(2), stats.WriteFailed)
+	assert.Equal(t, map[string]int32{failure.ClassDiskSpace: 1, failure.ClassWrite: 1}, stats.FailedReasons)
+}
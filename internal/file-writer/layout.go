@@ -0,0 +1,84 @@
+package filewriter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+// DestDirField is the CSV column naming the output directory of a single row.
+const DestDirField = "dest_dir"
+
+// maxSegments bounds how deep the host layout mirrors a URL path.
+const maxSegments = 32
+
+// Layout returns the slash separated directory, relative to the output root, that a download
+// named name is written to. A dest_dir column of the row takes precedence over the layout.
+func Layout(cfg config.WriteConfig, download *types.Download, name string, now time.Time) (string, error) {
+	if dir := download.Fields[DestDirField]; dir != "" {
+		return destDir(dir)
+	}
+
+	switch cfg.Layout {
+	case config.LayoutHash:
+		sum := sha256.Sum256([]byte(name))
+		digest := hex.EncodeToString(sum[:2])
+		return digest[:2] + "/" + digest[2:], nil
+	case config.LayoutHost:
+		return hostDir(download.URL), nil
+	case config.LayoutDate:
+		return now.UTC().Format("2006/01/02"), nil
+	}
+	return "", nil
+}
+
+// destDir checks a directory given in the CSV, which has to stay inside the output root.
+func destDir(dir string) (string, error) {
+	cleaned := filepath.Clean(filepath.FromSlash(strings.TrimSpace(dir)))
+	if !filepath.IsLocal(cleaned) {
+		return "", fmt.Errorf("%w: %s %q", ErrUnsafePath, DestDirField, dir)
+	}
+	if cleaned == "." {
+		return "", nil
+	}
+	return filepath.ToSlash(cleaned), nil
+}
+
+// hostDir mirrors host and path of the URL like wget does. Every segment is sanitized, so none of
+// them can be "..", absolute or contain a separator.
+func hostDir(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return "_"
+	}
+
+	segments := []string{segment(strings.ToLower(parsed.Host))}
+	dir := path.Dir(parsed.Path)
+	for _, s := range strings.Split(dir, "/") {
+		if s == "" || s == "." || len(segments) >= maxSegments {
+			continue
+		}
+		segments = append(segments, segment(s))
+	}
+	return strings.Join(segments, "/")
+}
+
+// segment turns an untrusted path segment into a safe directory name.
+func segment(s string) string {
+	s = sanitize(s)
+	if len(s) > maxNameLength {
+		s = s[:maxNameLength]
+	}
+	if strings.Trim(s, ".") == "" {
+		return strings.Repeat("_", len(s))
+	}
+	return s
+}
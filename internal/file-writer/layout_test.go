@@ -0,0 +1,107 @@
+package filewriter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	typeMocks "github.com/puruabhi/jfrog/home-assignment/internal/types/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayout(t *testing.T) {
+	download := &types.Download{URL: "https://Example.com:8080/a/b/c.html"}
+	now := time.Date(2026, 10, 19, 23, 0, 0, 0, time.FixedZone("X", -2*60*60))
+
+	tests := []struct {
+		layout   string
+		expected string
+	}{
+		{"", ""},
+		{config.LayoutFlat, ""},
+		{config.LayoutHash, "c3/08"},
+		{config.LayoutHost, "example.com_8080/a/b"},
+		{config.LayoutDate, "2026/10/20"},
+	}
+
+	for _, tt := range tests {
+		dir, err := Layout(config.WriteConfig{Layout: tt.layout}, download, "name.txt", now)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, dir, tt.layout)
+	}
+}
+
+func TestLayout_DestDir(t *testing.T) {
+	cfg := config.WriteConfig{Layout: config.LayoutHash}
+	layout := func(dir string) (string, error) {
+		download := &types.Download{URL: "https://example.com/", Fields: map[string]string{DestDirField: dir}}
+		return Layout(cfg, download, "name.txt", time.Now())
+	}
+
+	dir, err := layout("team/a/../b/")
+	assert.NoError(t, err)
+	assert.Equal(t, "team/b", dir)
+
+	dir, err = layout("./")
+	assert.NoError(t, err)
+	assert.Equal(t, "", dir)
+
+	for _, unsafe := range []string{"../x", "a/../../x", "/etc"} {
+		_, err := layout(unsafe)
+		assert.ErrorIs(t, err, ErrUnsafePath, unsafe)
+	}
+}
+
+func TestHostDir(t *testing.T) {
+	assert.Equal(t, "example.com", hostDir("https://example.com/"))
+	assert.Equal(t, "example.com", hostDir("https://example.com/file.txt"))
+	// Dot segments, encoded or not, are resolved below the host and never leave it.
+	assert.Equal(t, "example.com/etc", hostDir("https://example.com/../etc/passwd"))
+	assert.Equal(t, "example.com", hostDir("https://example.com/%2e%2e/%2E%2E/passwd"))
+	assert.Equal(t, "example.com/b", hostDir("https://example.com/a/%2e%2e/b/c"))
+	assert.Equal(t, "example.com/a/b", hostDir("https://example.com/a%2Fb/c"))
+	assert.Equal(t, "example.com/_x_", hostDir("https://example.com/%3Cx%3E/c"))
+	assert.Equal(t, "_", hostDir("not a url"))
+}
+
+func TestWrite_Layout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockManifest := typeMocks.NewMockManifestable(ctrl)
+	w := &fileWriter{
+		config:   config.WriteConfig{WriteDir: t.TempDir(), Naming: config.NamingSHA256, Layout: config.LayoutHost},
+		logger:   types.NewLoggerStub(),
+		reporter: types.NewReporterStub(),
+		manifest: mockManifest,
+		guard:    types.NewGuardStub(),
+		observer: types.NewObserverStub(),
+	}
+
+	var entry types.ManifestEntry
+	mockManifest.EXPECT().Append(gomock.Any()).DoAndReturn(func(e types.ManifestEntry) error {
+		entry = e
+		return nil
+	}).Times(1)
+
+	w.write(&types.Download{URL: "https://example.com/a/../../b/c.txt", Content: []byte("test data")})
+
+	name := "916f0027a575074ce72a331777c3478d6513f786a591bd892da1a577bf2335f9.txt"
+	content, err := os.ReadFile(filepath.Join(w.config.WriteDir, "example.com", "b", name))
+	assert.NoError(t, err)
+	assert.Equal(t, "test data", string(content))
+	assert.Equal(t, "example.com/b/"+name, entry.File)
+
+	// Rows pointing outside of the output directory are not written.
+	w.write(&types.Download{
+		URL:     "https://example.com/",
+		Content: []byte("test data"),
+		Fields:  map[string]string{DestDirField: "../outside"},
+	})
+	assert.Equal(t, int32(1), w.stats.writeFailed.Load())
+	assert.NoDirExists(t, filepath.Join(filepath.Dir(w.config.WriteDir), "outside"))
+}
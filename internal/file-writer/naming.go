@@ -0,0 +1,64 @@
+package filewriter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+// maxNameLength keeps names derived from URLs well below the common 255 byte file name limit.
+const maxNameLength = 200
+
+// FileName returns the name a download is written under, following the naming strategy.
+func FileName(strategy string, download *types.Download, content []byte) string {
+	switch strategy {
+	case config.NamingSHA256:
+		return sha256Hex(content) + fileExt
+	case config.NamingURL:
+		return urlName(download.URL)
+	}
+	return uuid.New().String() + fileExt
+}
+
+// urlName flattens host and path into a single safe name, keeping the extension of the path and
+// ending in a short hash of the full URL so that different URLs never share a name.
+func urlName(rawURL string) string {
+	ext := fileExt
+	name := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil {
+		name = parsed.Host + parsed.Path
+		if e := path.Ext(parsed.Path); e != "" && len(e) <= 10 {
+			ext = e
+			name = strings.TrimSuffix(name, e)
+		}
+	}
+
+	name = strings.Trim(sanitize(name), "_")
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+	return fmt.Sprintf("%s-%s%s", name, sha256Hex([]byte(rawURL))[:8], sanitize(ext))
+}
+
+// sanitize replaces everything but letters, digits, dots and dashes with underscores.
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
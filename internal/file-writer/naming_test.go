@@ -0,0 +1,33 @@
+package filewriter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileName(t *testing.T) {
+	data := []byte("test data")
+	download := &types.Download{URL: "https://example.com/a/b.html?q=1", Content: data}
+
+	name := FileName(config.NamingUUID, download, data)
+	assert.Len(t, name, 36+len(fileExt))
+	assert.NotEqual(t, name, FileName("", download, data))
+
+	assert.Equal(t, "916f0027a575074ce72a331777c3478d6513f786a591bd892da1a577bf2335f9.txt", FileName(config.NamingSHA256, download, data))
+
+	name = FileName(config.NamingURL, download, data)
+	assert.True(t, strings.HasPrefix(name, "example.com_a_b-"), name)
+	assert.True(t, strings.HasSuffix(name, ".html"), name)
+	assert.NotEqual(t, name, FileName(config.NamingURL, &types.Download{URL: "https://example.com/a/b.html?q=2"}, data))
+}
+
+func TestURLName(t *testing.T) {
+	assert.Regexp(t, `^example\.com-[0-9a-f]{8}\.txt$`, urlName("https://example.com"))
+	assert.Regexp(t, `^example\.com_8080_.._etc_passwd-[0-9a-f]{8}\.txt$`, urlName("https://example.com:8080/../etc/passwd"))
+	assert.Regexp(t, `^example\.com_a_b_c-[0-9a-f]{8}\.txt$`, urlName("https://example.com/a b/c"))
+	assert.LessOrEqual(t, len(urlName("https://example.com/"+strings.Repeat("a", 500))), maxNameLength+len("-12345678")+len(fileExt))
+}
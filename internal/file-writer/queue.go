@@ -0,0 +1,75 @@
+package filewriter
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+const (
+	DefaultConcurrency = 4
+	DefaultQueueSize   = 100
+)
+
+// Queued is a download waiting in a writer queue.
+type Queued struct {
+	Download *types.Download
+	At       time.Time
+}
+
+// NewQueued stamps the download with the time it entered the queue.
+func NewQueued(download *types.Download) *Queued {
+	return &Queued{Download: download, At: time.Now()}
+}
+
+// WaitStats tracks how long downloads wait in a writer queue before a worker picks them up.
+type WaitStats struct {
+	count atomic.Int64
+	total atomic.Int64
+	max   atomic.Int64
+}
+
+// Observe records the wait of a download taken off the queue and returns it.
+func (s *WaitStats) Observe(queued *Queued) time.Duration {
+	wait := int64(time.Since(queued.At))
+	s.count.Add(1)
+	s.total.Add(wait)
+	for {
+		current := s.max.Load()
+		if wait <= current || s.max.CompareAndSwap(current, wait) {
+			return time.Duration(wait)
+		}
+	}
+}
+
+// AvgMs returns the average wait in milliseconds.
+func (s *WaitStats) AvgMs() float64 {
+	count := s.count.Load()
+	if count == 0 {
+		return 0
+	}
+	return float64(s.total.Load()) / float64(count) / float64(time.Millisecond)
+}
+
+// MaxMs returns the longest wait in milliseconds.
+func (s *WaitStats) MaxMs() float64 {
+	return float64(s.max.Load()) / float64(time.Millisecond)
+}
+
+// Concurrency returns the number of writer workers, DefaultConcurrency when unset.
+func Concurrency(config config.WriteConfig) int {
+	if config.Concurrency > 0 {
+		return config.Concurrency
+	}
+	return DefaultConcurrency
+}
+
+// QueueSize returns the number of downloads queued for writing, DefaultQueueSize when unset.
+func QueueSize(config config.WriteConfig) int {
+	if config.QueueSize > 0 {
+		return config.QueueSize
+	}
+	return DefaultQueueSize
+}
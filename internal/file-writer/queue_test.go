@@ -0,0 +1,32 @@
+package filewriter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitStats(t *testing.T) {
+	var stats WaitStats
+	assert.Zero(t, stats.AvgMs())
+
+	now := time.Now()
+	stats.Observe(&Queued{At: now.Add(-10 * time.Millisecond)})
+	stats.Observe(&Queued{At: now.Add(-30 * time.Millisecond)})
+
+	assert.InDelta(t, 20, stats.AvgMs(), 5)
+	assert.InDelta(t, 30, stats.MaxMs(), 5)
+}
+
+func TestPoolDefaults(t *testing.T) {
+	assert.Equal(t, DefaultConcurrency, Concurrency(config.WriteConfig{}))
+	assert.Equal(t, DefaultQueueSize, QueueSize(config.WriteConfig{}))
+	assert.Equal(t, 8, Concurrency(config.WriteConfig{Concurrency: 8}))
+	assert.Equal(t, 1, QueueSize(config.WriteConfig{QueueSize: 1}))
+
+	queued := NewQueued(&types.Download{URL: "https://example.com/"})
+	assert.WithinDuration(t, time.Now(), queued.At, time.Second)
+}
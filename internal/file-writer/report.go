@@ -0,0 +1,24 @@
+package filewriter
+
+import (
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+// ReportWrite records the outcome of writing download in the report: downloaded, or write_failed
+// with the error and its class when err is not nil.
+func ReportWrite(reporter types.Reportable, download *types.Download, err error) {
+	entry := types.ReportEntry{
+		Row:       download.Row,
+		URL:       download.URL,
+		FinalURL:  download.FinalURL,
+		Redirects: download.Redirects,
+		Status:    types.ReportStatusDownloaded,
+	}
+	if err != nil {
+		entry.Status = types.ReportStatusWriteFailed
+		entry.Error = err.Error()
+		entry.ErrorClass = failure.ClassOf(err)
+	}
+	reporter.Record(entry)
+}
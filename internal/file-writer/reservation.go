@@ -0,0 +1,31 @@
+package filewriter
+
+import "github.com/puruabhi/jfrog/home-assignment/internal/types"
+
+// reservation holds the bytes reserved in the guard for a single write. They are given back when
+// the write fails or keeps an existing file, unless the write is committed.
+type reservation struct {
+	guard types.Guardable
+	size  int64
+}
+
+func (r *reservation) reserve(size int64) error {
+	if err := r.guard.Reserve(size); err != nil {
+		return err
+	}
+	r.size += size
+	return nil
+}
+
+// commit keeps the reserved bytes, as they were written.
+func (r *reservation) commit() {
+	r.size = 0
+}
+
+// release gives back the bytes reserved since the last commit.
+func (r *reservation) release() {
+	if r.size > 0 {
+		r.guard.Release(r.size)
+		r.size = 0
+	}
+}
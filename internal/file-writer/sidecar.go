@@ -0,0 +1,61 @@
+package filewriter
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+// SidecarExt is appended to the name of an output file to name its metadata sidecar.
+const SidecarExt = ".meta.json"
+
+// Sidecar is the metadata written next to an output file, so it can be processed without the manifest.
+type Sidecar struct {
+	URL        string              `json:"url"`
+	FinalURL   string              `json:"final_url,omitempty"`
+	Redirects  []string            `json:"redirects,omitempty"`
+	StatusCode int                 `json:"status_code"`
+	Header     map[string][]string `json:"header,omitempty"`
+	StartedAt  time.Time           `json:"started_at"`
+	FinishedAt time.Time           `json:"finished_at"`
+	WrittenAt  time.Time           `json:"written_at"`
+	SHA256     string              `json:"sha256"`
+	Size       int64               `json:"size"`
+}
+
+// NewSidecar builds the sidecar of a download written with size bytes. The digest is taken from the
+// written content, like in the manifest.
+func NewSidecar(download *types.Download, content []byte, size int64) Sidecar {
+	return Sidecar{
+		URL:        download.URL,
+		FinalURL:   download.FinalURL,
+		Redirects:  download.Redirects,
+		StatusCode: download.StatusCode,
+		Header:     download.Header,
+		StartedAt:  download.StartedAt,
+		FinishedAt: download.FinishedAt,
+		WrittenAt:  time.Now().UTC(),
+		SHA256:     sha256Hex(content),
+		Size:       size,
+	}
+}
+
+// writeSidecar writes the sidecar of filePath to a temp file, which place renames next to the content.
+// Its bytes are reserved along with the content in space. It returns "" when sidecars are disabled.
+func (w *fileWriter) writeSidecar(space *reservation, download *types.Download, content []byte, filePath string, size int64) (string, error) {
+	if !w.config.Sidecar {
+		return "", nil
+	}
+
+	data, err := json.MarshalIndent(NewSidecar(download, content, size), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("caught err while encoding sidecar: %w", err)
+	}
+	if err := space.reserve(int64(len(data))); err != nil {
+		return "", err
+	}
+	metaPath, _, err := writeTemp(filePath+SidecarExt, data, w.config.Fsync)
+	return metaPath, err
+}
@@ -0,0 +1,143 @@
+package filewriter
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func readSidecar(t *testing.T, filePath string) Sidecar {
+	data, err := os.ReadFile(filePath + SidecarExt)
+	assert.NoError(t, err)
+
+	var sidecar Sidecar
+	assert.NoError(t, json.Unmarshal(data, &sidecar))
+	return sidecar
+}
+
+func TestWrite_Sidecar(t *testing.T) {
+	w := newExistingTestWriter(t, config.ExistingFileRename)
+	w.config.Sidecar = true
+
+	started := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	download := &types.Download{
+		URL:        "https://example.com/a.txt",
+		FinalURL:   "https://cdn.example.com/a.txt",
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/plain"}},
+		Content:    []byte("test data"),
+		StartedAt:  started,
+		FinishedAt: started.Add(time.Second),
+	}
+	w.write(download)
+	w.write(download)
+
+	files := dirContent(t, w.config.WriteDir)
+	assert.Len(t, files, 4)
+	for name := range files {
+		if strings.HasSuffix(name, SidecarExt) {
+			continue
+		}
+		sidecar := readSidecar(t, filepath.Join(w.config.WriteDir, name))
+		assert.Equal(t, download.URL, sidecar.URL)
+		assert.Equal(t, download.FinalURL, sidecar.FinalURL)
+		assert.Equal(t, http.StatusOK, sidecar.StatusCode)
+		assert.Equal(t, []string{"text/plain"}, sidecar.Header["Content-Type"])
+		assert.Equal(t, started, sidecar.StartedAt)
+		assert.Equal(t, started.Add(time.Second), sidecar.FinishedAt)
+		assert.False(t, sidecar.WrittenAt.IsZero())
+		assert.Equal(t, sha256Hex(download.Content), sidecar.SHA256)
+		assert.Equal(t, int64(len(download.Content)), sidecar.Size)
+	}
+	stats := w.GetStats()
+	assert.Equal(t, int32(2), stats.Sidecars)
+	assert.Equal(t, int32(1), stats.ExistingRenamed)
+	assert.Equal(t, int64(2*len(download.Content)), stats.BytesWritten)
+}
+
+func TestWrite_SidecarKept(t *testing.T) {
+	w := newExistingTestWriter(t, config.ExistingFileSkip)
+	w.config.Sidecar = true
+
+	w.write(&types.Download{URL: "https://example.com/a.txt", Content: []byte("first")})
+	w.write(&types.Download{URL: "https://example.com/a.txt", Content: []byte("second")})
+
+	// The sidecar of a kept file is left as it was, and no temp file stays behind.
+	files := dirContent(t, w.config.WriteDir)
+	assert.Len(t, files, 2)
+	for name := range files {
+		if !strings.HasSuffix(name, SidecarExt) {
+			assert.Equal(t, int64(len("first")), readSidecar(t, filepath.Join(w.config.WriteDir, name)).Size)
+		}
+	}
+	assert.Equal(t, int32(1), w.stats.sidecars.Load())
+}
+
+func TestWrite_SidecarDisabled(t *testing.T) {
+	w := newExistingTestWriter(t, "")
+
+	w.write(&types.Download{URL: "https://example.com/a.txt", Content: []byte("test data")})
+
+	assert.Len(t, dirContent(t, w.config.WriteDir), 1)
+	assert.Equal(t, int32(0), w.stats.sidecars.Load())
+}
+
+func TestPlace_FailureKeepsExisting(t *testing.T) {
+	download := &types.Download{URL: "https://example.com/a.txt"}
+	tests := []struct {
+		name      string
+		sidecar   bool
+		content   bool
+		errString string
+	}{
+		// The sidecar temp file is gone, so placing it fails before the content is touched.
+		{"sidecar fails", false, true, "placing sidecar"},
+		// The content temp file is gone, so it fails to follow its sidecar.
+		{"content fails", true, false, "renaming temp file"},
+	}
+
+	for _, policy := range []string{config.ExistingFileOverwrite, config.ExistingFileChecksum} {
+		for _, test := range tests {
+			w := newExistingTestWriter(t, policy)
+			w.config.Sidecar = true
+			assert.NoError(t, w.write(&types.Download{URL: download.URL, Content: []byte("first")}))
+			files, err := os.ReadDir(w.config.WriteDir)
+			assert.NoError(t, err)
+			assert.Len(t, files, 2)
+			filePath := filepath.Join(w.config.WriteDir, strings.TrimSuffix(files[0].Name(), SidecarExt))
+			sidecar, err := os.ReadFile(filePath + SidecarExt)
+			assert.NoError(t, err)
+
+			tmpPath := filepath.Join(w.config.WriteDir, tempPrefix+"content")
+			metaPath := filepath.Join(w.config.WriteDir, tempPrefix+"meta")
+			if test.content {
+				assert.NoError(t, os.WriteFile(tmpPath, []byte("second"), 0644))
+			}
+			if test.sidecar {
+				assert.NoError(t, os.WriteFile(metaPath, []byte("{}"), 0644))
+			}
+
+			_, err = w.place(download, tmpPath, metaPath, filePath, []byte("second"))
+			assert.ErrorContains(t, err, test.errString, policy+": "+test.name)
+
+			// The existing file keeps its content and its sidecar, and nothing else is left behind.
+			content, err := os.ReadFile(filePath)
+			assert.NoError(t, err, policy+": "+test.name)
+			assert.Equal(t, "first", string(content), policy+": "+test.name)
+			kept, err := os.ReadFile(filePath + SidecarExt)
+			assert.NoError(t, err, policy+": "+test.name)
+			assert.Equal(t, sidecar, kept, policy+": "+test.name)
+			os.Remove(tmpPath)
+			assert.Len(t, dirContent(t, w.config.WriteDir), 2, policy+": "+test.name)
+			assert.Equal(t, int32(1), w.stats.sidecars.Load(), policy+": "+test.name)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package filewriter
+
+import (
+	"context"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StartWrite starts the write span of download as a child of its job span and returns the context
+// of the job. Until EndWrite, the download carries the write span, so that failures are recorded on it.
+func StartWrite(tracer types.Traceable, download *types.Download) context.Context {
+	job := download.Context()
+	download.Ctx, _ = tracer.Start(job, "write", trace.WithAttributes(attribute.Int("write.bytes", len(download.Content))))
+	return job
+}
+
+// EndWrite ends the write span of download and the span of its job, which ends with the write. The
+// job gets the status it has in the report, write_failed when err is not nil.
+func EndWrite(download *types.Download, job context.Context, err error) {
+	trace.SpanFromContext(download.Context()).End()
+	download.Ctx = job
+	if err != nil {
+		types.EndJob(job, types.ReportStatusWriteFailed, err)
+		return
+	}
+	types.EndJob(job, types.ReportStatusDownloaded, nil)
+}
@@ -0,0 +1,66 @@
+package filewriter
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type recordingTracer struct {
+	trace.Tracer
+}
+
+func (r recordingTracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return r.Tracer.Start(ctx, name, opts...)
+}
+
+func (r recordingTracer) Close() error { return nil }
+
+func TestWrite_Spans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := recordingTracer{sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")}
+
+	writer := &fileWriter{
+		config:   config.WriteConfig{WriteDir: t.TempDir()},
+		logger:   types.NewLoggerStub(),
+		reporter: types.NewReporterStub(),
+		manifest: types.NewManifestStub(),
+		guard:    types.NewGuardStub(),
+		observer: types.NewObserverStub(),
+		tracer:   tracer,
+	}
+
+	write := func(download *types.Download) {
+		download.Ctx, _ = tracer.Start(context.Background(), "job")
+		job := StartWrite(writer.tracer, download)
+		EndWrite(download, job, writer.write(download))
+		assert.Equal(t, job, download.Ctx)
+	}
+
+	write(&types.Download{URL: "https://example.com/a", Content: []byte("a")})
+	// The output directory is gone, so the next write fails.
+	writer.config.WriteDir = filepath.Join(writer.config.WriteDir, "missing")
+	write(&types.Download{URL: "https://example.com/b", Content: []byte("b")})
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 4)
+	for i, status := range []codes.Code{codes.Unset, codes.Error} {
+		writeSpan, job := spans[2*i], spans[2*i+1]
+		assert.Equal(t, "write", writeSpan.Name())
+		assert.Equal(t, status, writeSpan.Status().Code)
+		assert.Equal(t, "job", job.Name())
+		assert.Equal(t, job.SpanContext().SpanID(), writeSpan.Parent().SpanID())
+		assert.Equal(t, status, job.Status().Code)
+	}
+	assert.Contains(t, spans[1].Attributes(), attribute.String("job.status", types.ReportStatusDownloaded))
+	assert.Contains(t, spans[3].Attributes(), attribute.String("job.status", types.ReportStatusWriteFailed))
+}
@@ -0,0 +1,100 @@
+package latency
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+const (
+	// bucketsPerDoubling sets the resolution. Percentiles are off by at most 2^(1/4), about 19%.
+	bucketsPerDoubling = 4
+	// numBuckets covers durations up to 2^48 ns, about 78 hours.
+	numBuckets = 48 * bucketsPerDoubling
+)
+
+// Histogram counts durations in logarithmic buckets, so that percentiles can be estimated in
+// constant memory. It is safe for concurrent use.
+type Histogram struct {
+	mu     sync.Mutex
+	counts [numBuckets]int64
+	count  int64
+	sum    time.Duration
+	max    time.Duration
+}
+
+func bucket(d time.Duration) int {
+	if d < 1 {
+		return 0
+	}
+	i := int(math.Log2(float64(d)) * bucketsPerDoubling)
+	return min(i, numBuckets-1)
+}
+
+// upperBound returns the largest duration counted in bucket i.
+func upperBound(i int) time.Duration {
+	return time.Duration(math.Exp2(float64(i+1) / bucketsPerDoubling))
+}
+
+// Observe adds a duration.
+func (h *Histogram) Observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.counts[bucket(d)]++
+	h.count++
+	h.sum += d
+	h.max = max(h.max, d)
+}
+
+// Percentile estimates the duration below which p percent of the observations fall.
+func (h *Histogram) Percentile(p float64) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.percentile(p)
+}
+
+func (h *Histogram) percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+
+	rank := int64(math.Ceil(p / 100 * float64(h.count)))
+	var seen int64
+	for i, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			// The last bucket is open ended, and any bound may lie past the largest duration seen.
+			if i == numBuckets-1 {
+				return h.max
+			}
+			return min(upperBound(i), h.max)
+		}
+	}
+	return h.max
+}
+
+// Stats summarizes the histogram in milliseconds.
+func (h *Histogram) Stats() types.PhaseStats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.count == 0 {
+		return types.PhaseStats{}
+	}
+	return types.PhaseStats{
+		Count: h.count,
+		AvgMs: ms(h.sum / time.Duration(h.count)),
+		P50Ms: ms(h.percentile(50)),
+		P90Ms: ms(h.percentile(90)),
+		P99Ms: ms(h.percentile(99)),
+		MaxMs: ms(h.max),
+	}
+}
+
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
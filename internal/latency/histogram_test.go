@@ -0,0 +1,81 @@
+package latency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram(t *testing.T) {
+	h := &Histogram{}
+	for i := 1; i <= 100; i++ {
+		h.Observe(time.Duration(i) * time.Millisecond)
+	}
+
+	// Estimates are at most a bucket, about 19%, above the true percentile.
+	for _, tc := range []struct {
+		percentile float64
+		want       time.Duration
+	}{
+		{50, 50 * time.Millisecond},
+		{90, 90 * time.Millisecond},
+		{99, 99 * time.Millisecond},
+	} {
+		got := h.Percentile(tc.percentile)
+		assert.GreaterOrEqual(t, got, tc.want, "p%v", tc.percentile)
+		assert.LessOrEqual(t, float64(got), float64(tc.want)*1.19, "p%v", tc.percentile)
+	}
+	// The estimate never exceeds the largest duration seen.
+	assert.Equal(t, 100*time.Millisecond, h.Percentile(100))
+
+	stats := h.Stats()
+	assert.Equal(t, int64(100), stats.Count)
+	assert.Equal(t, 50.5, stats.AvgMs)
+	assert.Equal(t, 100.0, stats.MaxMs)
+}
+
+func TestHistogram_Empty(t *testing.T) {
+	h := &Histogram{}
+	assert.Equal(t, time.Duration(0), h.Percentile(50))
+	assert.Equal(t, types.PhaseStats{}, h.Stats())
+}
+
+func TestHistogram_Extremes(t *testing.T) {
+	h := &Histogram{}
+	h.Observe(0)
+	h.Observe(1000 * time.Hour)
+
+	assert.LessOrEqual(t, h.Percentile(50), time.Nanosecond)
+	// Durations past the last bucket are reported as the largest one seen.
+	assert.Equal(t, 1000*time.Hour, h.Percentile(100))
+}
+
+func TestPhases(t *testing.T) {
+	p := &Phases{}
+	assert.Nil(t, p.Stats())
+	assert.Empty(t, p.HostStats())
+
+	p.Observe("example.com", PhaseDNS, 2*time.Millisecond)
+	p.Observe("example.com", PhaseDNS, 4*time.Millisecond)
+	p.Observe("other.com", PhaseDNS, 6*time.Millisecond)
+	p.Observe("other.com", PhaseFsync, time.Millisecond)
+
+	stats := p.Stats()
+	assert.Equal(t, int64(3), stats[PhaseDNS].Count)
+	assert.Equal(t, 4.0, stats[PhaseDNS].AvgMs)
+	assert.Equal(t, int64(1), stats[PhaseFsync].Count)
+
+	hosts := p.HostStats()
+	assert.Equal(t, int64(2), hosts["example.com"][PhaseDNS].Count)
+	assert.Equal(t, 3.0, hosts["example.com"][PhaseDNS].AvgMs)
+	assert.NotContains(t, hosts["example.com"], PhaseFsync)
+	assert.Equal(t, 6.0, hosts["other.com"][PhaseDNS].MaxMs)
+}
+
+func TestHostOf(t *testing.T) {
+	assert.Equal(t, "example.com", HostOf("https://Example.COM:8443/a"))
+	assert.Equal(t, "unknown", HostOf("::invalid"))
+	assert.Equal(t, "unknown", HostOf("/relative"))
+}
@@ -0,0 +1,106 @@
+package latency
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+// Phases of a download, as traced by the downloader.
+const (
+	PhaseDNS      = "dns"
+	PhaseConnect  = "connect"
+	PhaseTLS      = "tls"
+	PhaseTTFB     = "ttfb"
+	PhaseTransfer = "transfer"
+)
+
+// Phases of a write. The S3 writer only has PhaseUpload.
+const (
+	PhaseWrite  = "write"
+	PhaseFsync  = "fsync"
+	PhasePlace  = "place"
+	PhaseUpload = "upload"
+)
+
+// Order lists all phases in the order they happen, for display.
+var Order = []string{PhaseDNS, PhaseConnect, PhaseTLS, PhaseTTFB, PhaseTransfer, PhaseWrite, PhaseFsync, PhasePlace, PhaseUpload}
+
+// Phases keeps a histogram per phase, both in total and per host. The zero value is ready to use.
+type Phases struct {
+	mu    sync.Mutex
+	total map[string]*Histogram
+	hosts map[string]map[string]*Histogram
+}
+
+// Observe adds the duration of phase for a URL of host.
+func (p *Phases) Observe(host, phase string, d time.Duration) {
+	p.mu.Lock()
+	if p.total == nil {
+		p.total = make(map[string]*Histogram)
+		p.hosts = make(map[string]map[string]*Histogram)
+	}
+	total := histogram(p.total, phase)
+	perHost, ok := p.hosts[host]
+	if !ok {
+		perHost = make(map[string]*Histogram)
+		p.hosts[host] = perHost
+	}
+	hostHistogram := histogram(perHost, phase)
+	p.mu.Unlock()
+
+	total.Observe(d)
+	hostHistogram.Observe(d)
+}
+
+func histogram(histograms map[string]*Histogram, phase string) *Histogram {
+	h, ok := histograms[phase]
+	if !ok {
+		h = &Histogram{}
+		histograms[phase] = h
+	}
+	return h
+}
+
+// Stats summarizes every phase across all hosts.
+func (p *Phases) Stats() map[string]types.PhaseStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return summarize(p.total)
+}
+
+// HostStats summarizes every phase per host.
+func (p *Phases) HostStats() map[string]map[string]types.PhaseStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	hosts := make(map[string]map[string]types.PhaseStats, len(p.hosts))
+	for host, histograms := range p.hosts {
+		hosts[host] = summarize(histograms)
+	}
+	return hosts
+}
+
+func summarize(histograms map[string]*Histogram) map[string]types.PhaseStats {
+	if len(histograms) == 0 {
+		return nil
+	}
+	stats := make(map[string]types.PhaseStats, len(histograms))
+	for phase, h := range histograms {
+		stats[phase] = h.Stats()
+	}
+	return stats
+}
+
+// HostOf returns the lowercased host name of rawURL, which keys the per-host stats.
+func HostOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Hostname() == "" {
+		return "unknown"
+	}
+	return strings.ToLower(parsed.Hostname())
+}
@@ -1,25 +1,54 @@
 package logger
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
 
-type fmtLogger struct{}
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+// fmtLogger prints every line as is, followed by its fields as key=value pairs.
+type fmtLogger struct {
+	out    io.Writer
+	fields []any
+}
 
 func NewFmtLogger() *fmtLogger {
-	return &fmtLogger{}
+	return &fmtLogger{out: os.Stdout}
 }
 
 func (l *fmtLogger) Debugf(format string, args ...any) {
-	fmt.Printf(format, args...)
+	l.printf(format, args...)
 }
 
 func (l *fmtLogger) Infof(format string, args ...any) {
-	fmt.Printf(format, args...)
+	l.printf(format, args...)
 }
 
 func (l *fmtLogger) Warnf(format string, args ...any) {
-	fmt.Printf(format, args...)
+	l.printf(format, args...)
 }
 
 func (l *fmtLogger) Errorf(format string, args ...any) {
-	fmt.Printf(format, args...)
+	l.printf(format, args...)
+}
+
+func (l *fmtLogger) With(fields ...any) types.Logger {
+	return &fmtLogger{out: l.out, fields: append(l.fields[:len(l.fields):len(l.fields)], fields...)}
+}
+
+// printf prints the message on a line of its own, whether or not format ends with a newline.
+func (l *fmtLogger) printf(format string, args ...any) {
+	var line strings.Builder
+	line.WriteString(strings.TrimSuffix(fmt.Sprintf(format, args...), "\n"))
+	for i := 0; i < len(l.fields); i += 2 {
+		if i+1 < len(l.fields) {
+			fmt.Fprintf(&line, " %v=%v", l.fields[i], l.fields[i+1])
+		} else {
+			fmt.Fprintf(&line, " %v", l.fields[i])
+		}
+	}
+	fmt.Fprintln(l.out, line.String())
 }
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFmtLogger(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := &fmtLogger{out: out}
+
+	logger.Infof("Started")
+	logger.Errorf("Failed: %s\n", "boom")
+	job := logger.With("job_id", "a1", "row", 3)
+	job.Debugf("Saved: %s", "out.txt")
+	logger.Warnf("Unrelated")
+
+	assert.Equal(t, "Started\nFailed: boom\nSaved: out.txt job_id=a1 row=3\nUnrelated\n", out.String())
+}
+
+func TestZapLogger_JSONFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger := NewZapLogger(config.LogConfig{Level: "warn", Format: config.LogFormatJSON, FilePath: path}, io.Discard)
+
+	logger.Infof("Filtered out")
+	logger.With("job_id", "a1", "row", 3, "url", "https://example.com").Warnf("Failed to save file: %s\n", "disk full")
+	assert.NoError(t, logger.Close())
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	assert.Len(t, lines, 1)
+
+	var line map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	assert.Equal(t, "warn", line["level"])
+	assert.Equal(t, "Failed to save file: disk full", line["msg"])
+	assert.Equal(t, "a1", line["job_id"])
+	assert.Equal(t, float64(3), line["row"])
+	assert.Equal(t, "https://example.com", line["url"])
+	assert.Contains(t, line["caller"], "logger/logger_test.go")
+}
+
+func TestZapLogger_ConsoleFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	logger := NewZapLogger(config.LogConfig{Level: "debug", FilePath: path}, io.Discard)
+
+	logger.With("row", 1).Debugf("Read URL")
+	assert.NoError(t, logger.Close())
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "DEBUG")
+	assert.Contains(t, string(content), "Read URL")
+	assert.Contains(t, string(content), `{"row": 1}`)
+	assert.NotContains(t, string(content), "\x1b[")
+}
+
+func TestZapLogger_Output(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := NewZapLogger(config.LogConfig{Format: config.LogFormatJSON}, out)
+
+	logger.Infof("Started")
+	assert.NoError(t, logger.Close())
+	assert.Contains(t, out.String(), `"msg":"Started"`)
+}
@@ -1,26 +1,99 @@
 package logger
 
 import (
+	"io"
+	"strings"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+// defaultMaxSizeMB is the size the log file is rotated at when none is configured.
+const defaultMaxSizeMB = 100
+
 type zapLogger struct {
 	*zap.SugaredLogger
+	// file is the rotated log file, or nil when logging to the given writer.
+	file io.Closer
+}
+
+// NewZapLogger creates a logger with the configured level and format, writing to out, usually
+// stderr, or to a file that is rotated as it grows.
+func NewZapLogger(cfg config.LogConfig, out io.Writer) *zapLogger {
+	level := zapcore.InfoLevel
+	if cfg.Level != "" {
+		// The level is validated with the config, so an unknown one keeps info.
+		_ = level.Set(cfg.Level)
+	}
+
+	encoderConfig := zap.NewProductionEncoderConfig()
+	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+
+	writer := zapcore.Lock(zapcore.AddSync(out))
+	var file io.Closer
+	if cfg.FilePath != "" {
+		rotated := &lumberjack.Logger{
+			Filename:   cfg.FilePath,
+			MaxSize:    cfg.MaxSizeMB,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAgeDays,
+			Compress:   cfg.Compress,
+		}
+		if rotated.MaxSize == 0 {
+			rotated.MaxSize = defaultMaxSizeMB
+		}
+		writer = zapcore.AddSync(rotated)
+		file = rotated
+	}
+
+	var encoder zapcore.Encoder
+	if cfg.Format == config.LogFormatJSON {
+		encoder = zapcore.NewJSONEncoder(encoderConfig)
+	} else {
+		// Colors only make sense on a terminal, not in a log file.
+		encoderConfig.EncodeLevel = zapcore.CapitalLevelEncoder
+		if file == nil {
+			encoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+		}
+		encoder = zapcore.NewConsoleEncoder(encoderConfig)
+	}
+
+	// The caller is skipped past the methods below, which trim the message.
+	logger := zap.New(zapcore.NewCore(encoder, writer, level), zap.AddCaller(), zap.AddCallerSkip(1),
+		zap.AddStacktrace(zapcore.ErrorLevel))
+	return &zapLogger{SugaredLogger: logger.Sugar(), file: file}
 }
 
-func NewZapLogger() *zapLogger {
-	config := zap.NewProductionConfig()
-	config.Level = zap.NewAtomicLevelAt(zapcore.InfoLevel) // Set default log level to info
+// Trailing newlines are left over from printf style logging, and would end up inside the message.
 
-	// Change the encoder configuration to human-readable format
-	config.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
-	config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
-	config.Encoding = "console"
+func (l *zapLogger) Debugf(format string, args ...any) {
+	l.SugaredLogger.Debugf(strings.TrimSuffix(format, "\n"), args...)
+}
+
+func (l *zapLogger) Infof(format string, args ...any) {
+	l.SugaredLogger.Infof(strings.TrimSuffix(format, "\n"), args...)
+}
+
+func (l *zapLogger) Warnf(format string, args ...any) {
+	l.SugaredLogger.Warnf(strings.TrimSuffix(format, "\n"), args...)
+}
+
+func (l *zapLogger) Errorf(format string, args ...any) {
+	l.SugaredLogger.Errorf(strings.TrimSuffix(format, "\n"), args...)
+}
 
-	logger, _ := config.Build()
-	defer logger.Sync()
+func (l *zapLogger) With(fields ...any) types.Logger {
+	return &zapLogger{SugaredLogger: l.SugaredLogger.With(fields...), file: l.file}
+}
 
-	sugar := logger.Sugar()
-	return &zapLogger{SugaredLogger: sugar}
+// Close flushes buffered lines and closes the log file, if any.
+func (l *zapLogger) Close() error {
+	_ = l.Sync()
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
 }
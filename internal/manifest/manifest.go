@@ -0,0 +1,110 @@
+package manifest
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+type manifest struct {
+	file    *os.File
+	encoder *json.Encoder
+	mu      sync.Mutex
+}
+
+// NewManifest opens the manifest at path for appending, so that entries of previous runs are kept.
+func NewManifest(path string) (*manifest, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("caught err while opening manifest: %w", err)
+	}
+
+	return &manifest{
+		file:    file,
+		encoder: json.NewEncoder(file),
+	}, nil
+}
+
+// Append writes the entry to the manifest.
+func (m *manifest) Append(entry types.ManifestEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.encoder.Encode(entry)
+}
+
+// Close flushes and closes the manifest.
+func (m *manifest) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.file.Sync(); err != nil {
+		m.file.Close()
+		return err
+	}
+	return m.file.Close()
+}
+
+// Read calls fn for every entry of the manifest at path. A missing manifest has no entries.
+func Read(path string, fn func(types.ManifestEntry) error) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("caught err while opening manifest: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		content, err := reader.ReadBytes('\n')
+		if len(content) > 0 {
+			var entry types.ManifestEntry
+			if jsonErr := json.Unmarshal(content, &entry); jsonErr != nil {
+				// A crash may leave a truncated last line behind, which is safe to skip.
+				if err == io.EOF {
+					return nil
+				}
+				return fmt.Errorf("invalid manifest entry on line %d: %w", line, jsonErr)
+			}
+			if fnErr := fn(entry); fnErr != nil {
+				return fnErr
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("caught err while reading manifest: %w", err)
+		}
+	}
+}
+
+// NewEntry builds the manifest entry for a download written to file.
+func NewEntry(download *types.Download, file string) types.ManifestEntry {
+	digest := download.SHA256
+	if digest == "" {
+		sum := sha256.Sum256(download.Content)
+		digest = hex.EncodeToString(sum[:])
+	}
+
+	return types.ManifestEntry{
+		URL:       download.URL,
+		FinalURL:  download.FinalURL,
+		File:      file,
+		Size:      len(download.Content),
+		SHA256:    digest,
+		WrittenAt: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,68 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(t *testing.T, path string) []types.ManifestEntry {
+	var entries []types.ManifestEntry
+	err := Read(path, func(entry types.ManifestEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	assert.NoError(t, err)
+	return entries
+}
+
+func TestAppendAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.jsonl")
+
+	m, err := NewManifest(path)
+	assert.NoError(t, err)
+	download := &types.Download{URL: "https://example.com/", FinalURL: "https://www.example.com/", Content: []byte("abc"), SHA256: "digest"}
+	assert.NoError(t, m.Append(NewEntry(download, "a.txt")))
+	assert.NoError(t, m.Close())
+
+	// A second run appends to the same manifest.
+	m, err = NewManifest(path)
+	assert.NoError(t, err)
+	assert.NoError(t, m.Append(types.ManifestEntry{URL: "https://example.org/", File: "b.txt"}))
+	assert.NoError(t, m.Close())
+
+	entries := readAll(t, path)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "https://example.com/", entries[0].URL)
+	assert.Equal(t, "https://www.example.com/", entries[0].FinalURL)
+	assert.Equal(t, "a.txt", entries[0].File)
+	assert.Equal(t, 3, entries[0].Size)
+	assert.Equal(t, "digest", entries[0].SHA256)
+	assert.Equal(t, "https://example.org/", entries[1].URL)
+}
+
+func TestRead_Missing(t *testing.T) {
+	entries := readAll(t, filepath.Join(t.TempDir(), "manifest.jsonl"))
+	assert.Empty(t, entries)
+}
+
+func TestRead_TruncatedLastLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.jsonl")
+	content := `{"url":"https://example.com/","file":"a.txt"}` + "\n" + `{"url":"https://exa`
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	entries := readAll(t, path)
+	assert.Len(t, entries, 1)
+}
+
+func TestRead_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.jsonl")
+	content := `not json` + "\n" + `{"url":"https://example.com/","file":"a.txt"}` + "\n"
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	err := Read(path, func(entry types.ManifestEntry) error { return nil })
+	assert.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+// stages are the JSON names of the stage stats in the snapshot. Everything else in it, such as the
+// per-host stats, is left out, hosts to keep the number of series bounded.
+var stages = map[string]bool{"csv_reader": true, "url_filter": true, "downloader": true, "writer": true, "disk_guard": true}
+
+// statsCollector exports the stats of a pipeline snapshot taken when the metrics are scraped.
+// A stat named field of a stage becomes <namespace>_<stage>_<field>, with a _total suffix
+// for counters, and a map of counts becomes a single metric labeled by reason. Stats tagged
+// metric:"gauge" are gauges, all others counters.
+type statsCollector struct {
+	mu       sync.Mutex
+	snapshot func() types.Snapshot
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{}
+}
+
+func (c *statsCollector) register(snapshot func() types.Snapshot) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.snapshot = snapshot
+}
+
+// Describe sends nothing, which makes the collector unchecked. The metrics are only known once
+// the stats are read.
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	snapshot := c.snapshot
+	c.mu.Unlock()
+	if snapshot == nil {
+		return
+	}
+
+	// Every stage is a field of the snapshot, named like in its JSON form.
+	stats := reflect.ValueOf(snapshot())
+	for i := 0; i < stats.NumField(); i++ {
+		stageName := jsonName(stats.Type().Field(i))
+		stage := reflect.Indirect(stats.Field(i))
+		if !stages[stageName] || stage.Kind() != reflect.Struct {
+			continue
+		}
+		for j := 0; j < stage.NumField(); j++ {
+			field := stage.Type().Field(j)
+			collectField(ch, stageName, jsonName(field), field.Tag.Get("metric") == "gauge", stage.Field(j))
+		}
+	}
+}
+
+func collectField(ch chan<- prometheus.Metric, stageName, field string, gauge bool, value reflect.Value) {
+	if value.Kind() == reflect.Map {
+		iter := value.MapRange()
+		for iter.Next() {
+			if f, ok := number(iter.Value()); ok {
+				collectValue(ch, stageName, field, gauge, f, []string{"reason"}, []string{iter.Key().String()})
+			}
+		}
+		return
+	}
+	if f, ok := number(value); ok {
+		collectValue(ch, stageName, field, gauge, f, nil, nil)
+	}
+}
+
+func collectValue(ch chan<- prometheus.Metric, stageName, field string, gauge bool, value float64, labels, labelValues []string) {
+	valueType := prometheus.GaugeValue
+	name := prometheus.BuildFQName(Namespace, stageName, field)
+	if !gauge {
+		valueType = prometheus.CounterValue
+		name += "_total"
+	}
+
+	desc := prometheus.NewDesc(name, "Stat "+field+" of the "+stageName+".", labels, nil)
+	metric, err := prometheus.NewConstMetric(desc, valueType, value, labelValues...)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(desc, err)
+		return
+	}
+	ch <- metric
+}
+
+// number returns the value of a numeric or boolean stat. Other stats, such as the phase
+// summaries, are not exported.
+func number(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Float64:
+		return value.Float(), true
+	case reflect.Bool:
+		if value.Bool() {
+			return 1, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// jsonName returns the name of a field in the JSON form of the stats.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return name
+}
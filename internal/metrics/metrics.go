@@ -0,0 +1,137 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+// Namespace prefixes the name of every exported metric.
+const Namespace = "home_assignment"
+
+const shutdownTimeout = 5 * time.Second
+
+// latencyBuckets cover everything from a cached response to a large download, in seconds.
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// phaseBuckets start lower, as a DNS lookup or a write to the page cache takes well under a millisecond.
+var phaseBuckets = []float64{.0001, .0005, .001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+type metrics struct {
+	logger   types.Logger
+	registry *prometheus.Registry
+	stats    *statsCollector
+	listener net.Listener
+	server   *http.Server
+
+	downloadDuration prometheus.Histogram
+	queueWait        prometheus.Histogram
+	writeDuration    prometheus.Histogram
+	phaseDuration    *prometheus.HistogramVec
+}
+
+// NewMetrics starts serving the Prometheus metrics on /metrics at the configured address.
+func NewMetrics(cfg config.MetricsConfig, logger types.Logger) (*metrics, error) {
+	m := &metrics{
+		logger:   logger,
+		registry: prometheus.NewRegistry(),
+		stats:    newStatsCollector(),
+		downloadDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "downloader",
+			Name:      "download_duration_seconds",
+			Help:      "Time taken to download a single URL, including failed attempts.",
+			Buckets:   latencyBuckets,
+		}),
+		queueWait: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "writer",
+			Name:      "queue_wait_seconds",
+			Help:      "Time a download waited in the writer queue.",
+			Buckets:   latencyBuckets,
+		}),
+		writeDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "writer",
+			Name:      "write_duration_seconds",
+			Help:      "Time taken to write or upload a single download.",
+			Buckets:   latencyBuckets,
+		}),
+		phaseDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Name:      "phase_duration_seconds",
+			Help:      "Time taken by one phase of a download or write, such as dns, ttfb or fsync.",
+			Buckets:   phaseBuckets,
+		}, []string{"phase"}),
+	}
+	m.registry.MustRegister(
+		m.stats,
+		m.downloadDuration,
+		m.queueWait,
+		m.writeDuration,
+		m.phaseDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	listener, err := net.Listen("tcp", cfg.Address)
+	if err != nil {
+		return nil, fmt.Errorf("caught err while listening for metrics: %w", err)
+	}
+	m.listener = listener
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	m.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	go func() {
+		if err := m.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			m.logger.Errorf("Metrics server stopped: %s", err)
+		}
+	}()
+
+	m.logger.Infof("Serving metrics on http://%s/metrics", listener.Addr())
+	return m, nil
+}
+
+// Register exports the stats of every stage. A snapshot is taken on every scrape.
+func (m *metrics) Register(snapshot func() types.Snapshot) {
+	m.stats.register(snapshot)
+}
+
+func (m *metrics) ObserveDownload(duration time.Duration) {
+	m.downloadDuration.Observe(duration.Seconds())
+}
+
+func (m *metrics) ObserveQueueWait(duration time.Duration) {
+	m.queueWait.Observe(duration.Seconds())
+}
+
+func (m *metrics) ObserveWrite(duration time.Duration) {
+	m.writeDuration.Observe(duration.Seconds())
+}
+
+func (m *metrics) ObservePhase(phase string, duration time.Duration) {
+	m.phaseDuration.WithLabelValues(phase).Observe(duration.Seconds())
+}
+
+// Addr returns the address the metrics are served on.
+func (m *metrics) Addr() string {
+	return m.listener.Addr().String()
+}
+
+// Close stops the metrics server, waiting for running scrapes to finish.
+func (m *metrics) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return m.server.Shutdown(ctx)
+}
@@ -0,0 +1,122 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func scrape(t *testing.T, addr string) string {
+	resp, err := http.Get("http://" + addr + "/metrics")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	m, err := NewMetrics(config.MetricsConfig{Address: "127.0.0.1:0"}, types.NewLoggerStub())
+	assert.NoError(t, err)
+	defer m.Close()
+
+	m.Register(func() types.Snapshot {
+		return types.Snapshot{
+			Time:   time.Now(),
+			Reader: types.ReaderStats{URLsRead: 10},
+			Filter: types.FilterStats{Invalid: 4, InvalidReasons: map[string]int32{"bad_scheme": 4}},
+			Downloader: types.DownloaderStats{
+				ActiveDownloads: 3,
+				TooLarge:        2,
+				BytesDownloaded: 1024,
+			},
+			Writer: types.WriterStats{QueueDepth: 5},
+		}
+	})
+
+	m.ObserveDownload(200 * time.Millisecond)
+	m.ObserveQueueWait(time.Millisecond)
+	m.ObserveWrite(time.Second)
+	m.ObservePhase("dns", 2*time.Millisecond)
+
+	body := scrape(t, m.Addr())
+	assert.Contains(t, body, "# TYPE home_assignment_downloader_active_downloads gauge\nhome_assignment_downloader_active_downloads 3\n")
+	assert.Contains(t, body, "# TYPE home_assignment_downloader_too_large_total counter\nhome_assignment_downloader_too_large_total 2\n")
+	assert.Contains(t, body, "home_assignment_downloader_bytes_downloaded_total 1024\n")
+	assert.Contains(t, body, "home_assignment_csv_reader_urls_read_total 10\n")
+	assert.Contains(t, body, `home_assignment_url_filter_invalid_reasons_total{reason="bad_scheme"} 4`)
+	assert.Contains(t, body, "# TYPE home_assignment_writer_queue_depth gauge\nhome_assignment_writer_queue_depth 5\n")
+	// Without a guard its stats are left out.
+	assert.NotContains(t, body, "home_assignment_disk_guard")
+	assert.NotContains(t, body, "home_assignment_time")
+
+	assert.Contains(t, body, `home_assignment_downloader_download_duration_seconds_bucket{le="0.25"} 1`)
+	assert.Contains(t, body, "home_assignment_writer_queue_wait_seconds_count 1\n")
+	assert.Contains(t, body, "home_assignment_writer_write_duration_seconds_sum 1\n")
+	assert.Contains(t, body, `home_assignment_phase_duration_seconds_bucket{phase="dns",le="0.005"} 1`)
+	assert.Contains(t, body, "go_goroutines")
+}
+
+func TestMetrics_AddressInUse(t *testing.T) {
+	m, err := NewMetrics(config.MetricsConfig{Address: "127.0.0.1:0"}, types.NewLoggerStub())
+	assert.NoError(t, err)
+	defer m.Close()
+
+	_, err = NewMetrics(config.MetricsConfig{Address: m.Addr()}, types.NewLoggerStub())
+	assert.Error(t, err)
+}
+
+func TestMetrics_Close(t *testing.T) {
+	m, err := NewMetrics(config.MetricsConfig{Address: "127.0.0.1:0"}, types.NewLoggerStub())
+	assert.NoError(t, err)
+
+	assert.NoError(t, m.Close())
+	_, err = http.Get("http://" + m.Addr() + "/metrics")
+	assert.Error(t, err)
+}
+
+func TestStatsCollector_Guard(t *testing.T) {
+	m, err := NewMetrics(config.MetricsConfig{Address: "127.0.0.1:0"}, types.NewLoggerStub())
+	assert.NoError(t, err)
+	defer m.Close()
+
+	m.Register(func() types.Snapshot {
+		return types.Snapshot{Guard: &types.GuardStats{Paused: true, Pauses: 2, WrittenBytes: 100}}
+	})
+
+	body := scrape(t, m.Addr())
+	assert.Contains(t, body, "# TYPE home_assignment_disk_guard_paused gauge\nhome_assignment_disk_guard_paused 1\n")
+	assert.Contains(t, body, "home_assignment_disk_guard_pauses_total 2\n")
+	// Written bytes go down again when a write fails.
+	assert.Contains(t, body, "# TYPE home_assignment_disk_guard_written_bytes gauge\nhome_assignment_disk_guard_written_bytes 100\n")
+}
+
+func TestStatsCollector_Hosts(t *testing.T) {
+	m, err := NewMetrics(config.MetricsConfig{Address: "127.0.0.1:0"}, types.NewLoggerStub())
+	assert.NoError(t, err)
+	defer m.Close()
+
+	m.Register(func() types.Snapshot {
+		return types.Snapshot{
+			Downloader: types.DownloaderStats{DownloadSuccessful: 1},
+			Hosts: map[string]types.HostStats{
+				"example.com": {Requests: 2, Downloaded: 1, Failed: 1, FailedReasons: map[string]int32{"http_5xx": 1}},
+			},
+			RecentErrors: []types.RecentError{{URL: "https://example.com/a", Error: "502 Bad Gateway"}},
+		}
+	})
+
+	// Hosts are not stages, and must not break the scrape.
+	body := scrape(t, m.Addr())
+	assert.Contains(t, body, "home_assignment_downloader_download_successful_total 1\n")
+	assert.NotContains(t, body, "home_assignment_hosts")
+	assert.NotContains(t, body, "example.com")
+	assert.NotContains(t, body, "home_assignment_recent_errors")
+}
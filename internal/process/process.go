@@ -2,60 +2,249 @@ package process
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"os"
 	"time"
 
+	"github.com/puruabhi/jfrog/home-assignment/internal/cache"
 	"github.com/puruabhi/jfrog/home-assignment/internal/config"
 	csvreader "github.com/puruabhi/jfrog/home-assignment/internal/csv-reader"
+	diskguard "github.com/puruabhi/jfrog/home-assignment/internal/disk-guard"
 	"github.com/puruabhi/jfrog/home-assignment/internal/downloader"
 	filewriter "github.com/puruabhi/jfrog/home-assignment/internal/file-writer"
+	"github.com/puruabhi/jfrog/home-assignment/internal/latency"
 	"github.com/puruabhi/jfrog/home-assignment/internal/logger"
+	"github.com/puruabhi/jfrog/home-assignment/internal/manifest"
+	"github.com/puruabhi/jfrog/home-assignment/internal/metrics"
+	"github.com/puruabhi/jfrog/home-assignment/internal/progress"
+	"github.com/puruabhi/jfrog/home-assignment/internal/report"
+	s3writer "github.com/puruabhi/jfrog/home-assignment/internal/s3-writer"
+	"github.com/puruabhi/jfrog/home-assignment/internal/tracing"
 	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	urlfilter "github.com/puruabhi/jfrog/home-assignment/internal/url-filter"
 )
 
+// StatsInterval is how often stats are logged when no progress view is shown.
+const StatsInterval = 5 * time.Second
+
 type process struct {
 	finish     chan struct{}
 	logger     types.Logger
 	csvReader  types.Readable
+	filter     types.Filterable
 	downloader types.Downloadable
 	writer     types.Writable
-	config     *config.Config
-	ctx        context.Context
+	reporter   types.Reportable
+	manifest   types.Manifestable
+	cache      types.Cacheable
+	guard      types.Guardable
+	observer   types.Observable
+	tracer     types.Traceable
+	// progress is nil unless the live progress view is shown.
+	progress     types.Progressable
+	stopStats    chan struct{}
+	statsStopped chan struct{}
+	config       *config.Config
+	ctx          context.Context
 }
 
-// Setup initializes the process and starts the setup routine.
+// Setup initializes the process components and starts waiting for the run to finish. Errors setting
+// up are returned once the components created so far are closed.
 func Setup() (chan struct{}, error) {
 	finished := make(chan struct{})
-	log := logger.NewZapLogger()
+	// The logger is replaced by the configured one once the config is parsed.
+	log := logger.NewZapLogger(config.LogConfig{}, os.Stderr)
 	prc := &process{
 		finish: finished,
 		logger: log,
 		ctx:    context.Background(),
 	}
 
-	go prc.setup()
-	go prc.printPeriodicStats()
+	if err := prc.setup(); err != nil {
+		prc.logger.Errorf("Failed to set up: %s", err)
+		prc.abort()
+		return nil, err
+	}
+
+	go prc.waitAndFinish()
 	return finished, nil
 }
 
-// setup configures the process components and starts the waitAndFinish routine.
+// setup configures the process components. Once it succeeds, waitAndFinish waits for the run to end.
 func (prc *process) setup() error {
-	defer prc.waitAndFinish()
-
 	cfg, err := config.NewConfig()
 	if err != nil {
 		return err
 	}
 	prc.config = cfg
+	prc.logger = logger.NewZapLogger(cfg.Log, prc.setupProgress())
 
-	prc.writer = filewriter.NewFileWriter(prc.ctx, prc.config.Write, prc.logger)
-	prc.downloader = downloader.NewDownloader(prc.ctx, prc.logger, prc.csvReader, prc.writer)
+	if err := prc.setupObserver(); err != nil {
+		return err
+	}
+	if err := prc.setupTracer(); err != nil {
+		return err
+	}
+	if err := prc.setupReporter(); err != nil {
+		return err
+	}
+
+	if err := prc.setupManifest(); err != nil {
+		return err
+	}
+	if err := prc.setupCache(); err != nil {
+		return err
+	}
 
-	csvReader, err := csvreader.NewCSVReader(prc.config.Read, prc.logger, prc.downloader.GetURLsChan())
+	prc.setupGuard()
+	if err := prc.setupWriter(); err != nil {
+		return err
+	}
+	downloader, err := downloader.NewDownloader(prc.ctx, prc.config.Download, prc.logger, prc.csvReader, prc.writer, prc.reporter, prc.cache, prc.guard, prc.observer, prc.tracer)
+	if err != nil {
+		return err
+	}
+	prc.downloader = downloader
+
+	filter, err := urlfilter.NewURLFilter(prc.ctx, prc.config.Filter, prc.logger, prc.reporter, prc.downloader.GetURLsChan())
+	if err != nil {
+		return err
+	}
+	prc.filter = filter
+
+	csvReader, err := csvreader.NewCSVReader(prc.config.Read, prc.logger, prc.tracer, prc.filter.GetURLsChan())
 	if err != nil {
 		return err
 	}
 	prc.csvReader = csvReader
 
+	prc.registerStats()
+	prc.stopStats = make(chan struct{})
+	prc.statsStopped = make(chan struct{})
+	go prc.printPeriodicStats()
+	return nil
+}
+
+// setupWriter creates the S3 or archive writer if one is configured, or the file writer otherwise.
+func (prc *process) setupWriter() error {
+	switch {
+	case prc.config.Write.S3.Bucket != "":
+		writer, err := s3writer.NewS3Writer(prc.ctx, prc.config.Write, prc.logger, prc.reporter, prc.manifest, prc.guard, prc.observer, prc.tracer)
+		if err != nil {
+			return err
+		}
+		prc.writer = writer
+	case prc.config.Write.Archive.Format != "":
+		writer, err := filewriter.NewArchiveWriter(prc.ctx, prc.config.Write, prc.logger, prc.reporter, prc.manifest, prc.guard, prc.observer, prc.tracer)
+		if err != nil {
+			return err
+		}
+		prc.writer = writer
+	default:
+		prc.writer = filewriter.NewFileWriter(prc.ctx, prc.config.Write, prc.logger, prc.reporter, prc.manifest, prc.guard, prc.observer, prc.tracer)
+	}
+	return nil
+}
+
+// setupGuard creates the disk guard if a low-water mark or an output quota is configured.
+func (prc *process) setupGuard() {
+	space := prc.config.Write.Space
+	if space.MinFreeBytes == 0 && space.MaxTotalBytes == 0 {
+		prc.guard = types.NewGuardStub()
+		return
+	}
+	prc.guard = diskguard.NewDiskGuard(prc.ctx, prc.config.Write, prc.logger)
+}
+
+// setupObserver starts serving metrics if a metrics address is configured.
+func (prc *process) setupObserver() error {
+	if prc.config.Metrics.Address == "" {
+		prc.observer = types.NewObserverStub()
+		return nil
+	}
+
+	observer, err := metrics.NewMetrics(prc.config.Metrics, prc.logger)
+	if err != nil {
+		return err
+	}
+	prc.observer = observer
+	return nil
+}
+
+// setupTracer starts exporting spans if a tracing exporter is configured.
+func (prc *process) setupTracer() error {
+	if prc.config.Tracing.Exporter == "" {
+		prc.tracer = types.NewTracerStub()
+		return nil
+	}
+
+	tracer, err := tracing.NewTracer(prc.ctx, prc.config.Tracing, prc.logger)
+	if err != nil {
+		return err
+	}
+	prc.tracer = tracer
+	return nil
+}
+
+// registerStats exports the stats of every stage once all of them are set up.
+func (prc *process) registerStats() {
+	prc.observer.Register(prc.Snapshot)
+}
+
+// setupProgress shows the live progress view instead of logging stats when stdout is a terminal.
+// It returns the writer logs go to, which writes them above the view while it is shown.
+func (prc *process) setupProgress() io.Writer {
+	if prc.config.Progress.Disabled || !progress.IsTerminal(os.Stdout) {
+		return os.Stderr
+	}
+	view := progress.NewProgress(os.Stdout)
+	prc.progress = view
+	return view.Logs(os.Stderr)
+}
+
+// setupReporter creates the per-URL report if a report file is configured.
+func (prc *process) setupReporter() error {
+	if prc.config.Report.FilePath == "" {
+		prc.reporter = types.NewReporterStub()
+		return nil
+	}
+
+	reporter, err := report.NewReporter(prc.config.Report, prc.logger)
+	if err != nil {
+		return err
+	}
+	prc.reporter = reporter
+	return nil
+}
+
+// setupManifest opens the output manifest if a manifest file is configured.
+func (prc *process) setupManifest() error {
+	if prc.config.Write.ManifestFile == "" {
+		prc.manifest = types.NewManifestStub()
+		return nil
+	}
+
+	manifest, err := manifest.NewManifest(prc.config.Write.ManifestFile)
+	if err != nil {
+		return err
+	}
+	prc.manifest = manifest
+	return nil
+}
+
+// setupCache loads the conditional request cache if a cache file is configured.
+func (prc *process) setupCache() error {
+	if prc.config.Download.Cache.FilePath == "" {
+		prc.cache = types.NewCacheStub()
+		return nil
+	}
+
+	cache, err := cache.NewCache(prc.config.Download.Cache, prc.logger)
+	if err != nil {
+		return err
+	}
+	prc.cache = cache
 	return nil
 }
 
@@ -66,6 +255,16 @@ func (prc *process) waitAndFinish() {
 	if prc.csvReader != nil {
 		prc.csvReader.Close()
 	}
+	if err := prc.writer.Close(); err != nil {
+		prc.logger.Errorf("Failed to close writer: %s", err)
+	}
+	prc.guard.Close()
+	if prc.stopStats != nil {
+		close(prc.stopStats)
+		<-prc.statsStopped
+	}
+	prc.printSummary()
+	prc.close()
 	prc.finish <- struct{}{}
 
 	time.AfterFunc(2*time.Second, func() {
@@ -73,34 +272,176 @@ func (prc *process) waitAndFinish() {
 	})
 }
 
+// abort releases what setup created before it failed. The pipeline never ran, so there is
+// nothing to wait for or summarize.
+func (prc *process) abort() {
+	if prc.writer != nil {
+		if err := prc.writer.Close(); err != nil {
+			prc.logger.Errorf("Failed to close writer: %s", err)
+		}
+	}
+	if prc.guard != nil {
+		prc.guard.Close()
+	}
+	prc.close()
+}
+
+// close stops the metrics server, flushes the spans and closes the output files and the logger,
+// skipping the components setup did not get to.
+func (prc *process) close() {
+	if prc.observer != nil {
+		if err := prc.observer.Close(); err != nil {
+			prc.logger.Errorf("Failed to stop metrics server: %s", err)
+		}
+	}
+	if prc.tracer != nil {
+		if err := prc.tracer.Close(); err != nil {
+			prc.logger.Errorf("Failed to export spans: %s", err)
+		}
+	}
+	if prc.reporter != nil {
+		if err := prc.reporter.Close(); err != nil {
+			prc.logger.Errorf("Failed to close report: %s", err)
+		}
+	}
+	if prc.manifest != nil {
+		if err := prc.manifest.Close(); err != nil {
+			prc.logger.Errorf("Failed to close manifest: %s", err)
+		}
+	}
+	if prc.cache != nil {
+		if err := prc.cache.Close(); err != nil {
+			prc.logger.Errorf("Failed to save cache: %s", err)
+		}
+	}
+	if closer, ok := prc.logger.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// printPeriodicStats logs the stats, or redraws the progress view, until stopStats is closed.
 func (prc *process) printPeriodicStats() {
-	ticker := time.NewTicker(5 * time.Second)
+	defer close(prc.statsStopped)
+
+	interval := StatsInterval
+	if prc.progress != nil {
+		interval = progress.Interval
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
-			prc.printCSVReaderStats()
-			prc.printDownloaderStats()
-			prc.printWriterStats()
+			if prc.progress != nil {
+				prc.progress.Render(prc.Snapshot())
+				continue
+			}
+			prc.printStats(prc.Snapshot())
 
+		case <-prc.stopStats:
+			return
 		case <-prc.ctx.Done():
 			return
 		}
 	}
 }
 
-func (prc *process) printCSVReaderStats() {
-	readUrls := prc.csvReader.GetReadURLs()
-	prc.logger.Infof("CSV Reader: urls read: %d", readUrls)
+// Snapshot reads the stats of every stage of the pipeline. The CSV reader is set up last, and
+// counts as having read nothing of an unknown total until then.
+func (prc *process) Snapshot() types.Snapshot {
+	snapshot := types.Snapshot{
+		Time:         time.Now().UTC(),
+		Reader:       types.ReaderStats{TotalURLs: -1},
+		Filter:       prc.filter.GetStats(),
+		Downloader:   prc.downloader.GetStats(),
+		Writer:       prc.writer.GetStats(),
+		Guard:        prc.guard.GetStats(),
+		Hosts:        prc.downloader.GetHostStats(),
+		RecentErrors: prc.downloader.GetRecentErrors(),
+	}
+	if prc.csvReader != nil {
+		snapshot.Reader.URLsRead = prc.csvReader.GetReadURLs()
+		snapshot.Reader.TotalURLs = prc.csvReader.GetTotalURLs()
+	}
+
+	// The write phases join the download phases of the same host.
+	for host, phases := range prc.writer.GetHostPhases() {
+		stats := snapshot.Hosts[host]
+		if stats.Phases == nil {
+			stats.Phases = make(map[string]types.PhaseStats, len(phases))
+		}
+		for phase, phaseStats := range phases {
+			stats.Phases[phase] = phaseStats
+		}
+		snapshot.Hosts[host] = stats
+	}
+	return snapshot
+}
+
+func (prc *process) printStats(snapshot types.Snapshot) {
+	prc.logger.Infof("CSV Reader: %+v", snapshot.Reader)
+	prc.logger.Infof("URL Filter: %+v", snapshot.Filter)
+	prc.logger.Infof("URL Downloader: %+v", snapshot.Downloader)
+	prc.logger.Infof("File Writer: %+v", snapshot.Writer)
+	if snapshot.Guard != nil {
+		prc.logger.Infof("Disk Guard: %+v", *snapshot.Guard)
+	}
+	prc.printWorstHosts(snapshot)
+}
+
+// printWorstHosts logs the results of the hosts failing the most.
+func (prc *process) printWorstHosts(snapshot types.Snapshot) {
+	for _, host := range progress.WorstHosts(snapshot.Hosts, progress.TopHosts) {
+		stats := snapshot.Hosts[host]
+		prc.logger.Warnf("Failing host %s: %d requests, %d ok, %d failed (%.0f%%) %v, avg %.2fms, %d bytes",
+			host, stats.Requests, stats.Downloaded, stats.Failed, progress.FailureRate(stats)*100,
+			stats.FailedReasons, stats.AvgLatencyMs, stats.Bytes)
+	}
+}
+
+// printSummary logs why the run ended together with the final stats of every stage.
+func (prc *process) printSummary() {
+	reason := "completed"
+	if err := prc.guard.StopReason(); err != nil {
+		reason = fmt.Sprintf("stopped: %s", err)
+	}
+	prc.logger.Infof("Run summary: %s", reason)
+
+	snapshot := prc.Snapshot()
+	if prc.progress != nil {
+		prc.progress.Finish(snapshot)
+	}
+	prc.printStats(snapshot)
+	prc.printPhases(snapshot)
+	if prc.config.Report.HostsFilePath != "" {
+		if err := report.WriteHosts(prc.config.Report.HostsFilePath, snapshot); err != nil {
+			prc.logger.Errorf("Failed to write hosts report: %s", err)
+		}
+	}
 }
 
-func (prc *process) printDownloaderStats() {
-	stats := prc.downloader.GetStats()
-	prc.logger.Infof("URL Downloader: %+v", stats)
+// printPhases logs the latency percentiles of every phase, in total and for the busiest hosts.
+func (prc *process) printPhases(snapshot types.Snapshot) {
+	for _, phase := range latency.Order {
+		if stats, ok := snapshot.Downloader.Phases[phase]; ok {
+			prc.logger.Infof("Phase %s: %s", phase, formatPhase(stats))
+		} else if stats, ok := snapshot.Writer.Phases[phase]; ok {
+			prc.logger.Infof("Phase %s: %s", phase, formatPhase(stats))
+		}
+	}
+
+	for _, host := range progress.BusiestHosts(snapshot.Hosts, progress.TopHosts) {
+		phases := snapshot.Hosts[host].Phases
+		for _, phase := range latency.Order {
+			if stats, ok := phases[phase]; ok {
+				prc.logger.Infof("Host %s phase %s: %s", host, phase, formatPhase(stats))
+			}
+		}
+	}
 }
 
-func (prc *process) printWriterStats() {
-	stats := prc.writer.GetStats()
-	prc.logger.Infof("File Writer: %+v", stats)
+func formatPhase(stats types.PhaseStats) string {
+	return fmt.Sprintf("count %d, avg %.2fms, p50 %.2fms, p90 %.2fms, p99 %.2fms, max %.2fms",
+		stats.Count, stats.AvgMs, stats.P50Ms, stats.P90Ms, stats.P99Ms, stats.MaxMs)
 }
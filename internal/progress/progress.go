@@ -0,0 +1,271 @@
+package progress
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+const (
+	// Interval is how often the progress view is redrawn.
+	Interval = time.Second
+	// TopHosts is the number of hosts shown, the ones with the most downloads first.
+	TopHosts = 5
+	// maxWidth keeps lines from wrapping, which would break redrawing in place.
+	maxWidth = 100
+	barWidth = 30
+)
+
+// progress redraws a block of lines on a terminal with the state of the run.
+type progress struct {
+	// mu keeps log lines written through Logs from interleaving with a redraw.
+	mu    sync.Mutex
+	out   io.Writer
+	drawn []string
+	start types.Snapshot
+	last  types.Snapshot
+}
+
+// NewProgress creates a progress view drawing to out, which is expected to be a terminal.
+func NewProgress(out io.Writer) *progress {
+	return &progress{out: out}
+}
+
+// IsTerminal reports whether file is an interactive terminal that can be redrawn.
+func IsTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0 && os.Getenv("TERM") != "dumb"
+}
+
+// Render replaces the previous view with one of snapshot.
+func (p *progress) Render(snapshot types.Snapshot) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.render(snapshot)
+}
+
+// Finish draws the final view and leaves it on the screen.
+func (p *progress) Finish(snapshot types.Snapshot) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.render(snapshot)
+	p.drawn = nil
+}
+
+// Logs returns a writer for log lines going to out while the view is shown. The view is cleared
+// before every write and drawn again below it, so the lines end up above the view instead of
+// being overwritten by the next redraw.
+func (p *progress) Logs(out io.Writer) io.Writer {
+	return writerFunc(func(b []byte) (int, error) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		var buf bytes.Buffer
+		p.clear(&buf)
+		p.out.Write(buf.Bytes())
+		n, err := out.Write(b)
+
+		buf.Reset()
+		draw(&buf, p.drawn)
+		p.out.Write(buf.Bytes())
+		return n, err
+	})
+}
+
+func (p *progress) render(snapshot types.Snapshot) {
+	if p.start.Time.IsZero() {
+		p.start = snapshot
+		p.last = snapshot
+	}
+
+	lines := p.view(snapshot)
+	for i, line := range lines {
+		lines[i] = truncate(line, maxWidth)
+	}
+	var buf bytes.Buffer
+	p.clear(&buf)
+	draw(&buf, lines)
+	p.out.Write(buf.Bytes())
+
+	p.drawn = lines
+	p.last = snapshot
+}
+
+// clear moves to the start of the first line drawn last time and clears everything below.
+func (p *progress) clear(buf *bytes.Buffer) {
+	if len(p.drawn) > 0 {
+		fmt.Fprintf(buf, "\033[%dF\033[J", len(p.drawn))
+	}
+}
+
+func draw(buf *bytes.Buffer, lines []string) {
+	for _, line := range lines {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+}
+
+type writerFunc func(b []byte) (int, error)
+
+func (f writerFunc) Write(b []byte) (int, error) {
+	return f(b)
+}
+
+func (p *progress) view(snapshot types.Snapshot) []string {
+	reader, downloader, writer := snapshot.Reader, snapshot.Downloader, snapshot.Writer
+
+	lines := []string{p.rowsLine(reader)}
+
+	elapsed := snapshot.Time.Sub(p.last.Time).Seconds()
+	var rowsPerSec, bytesPerSec float64
+	if elapsed > 0 {
+		rowsPerSec = float64(reader.URLsRead-p.last.Reader.URLsRead) / elapsed
+		bytesPerSec = float64(downloader.BytesDownloaded-p.last.Downloader.BytesDownloaded) / elapsed
+	}
+	lines = append(lines, fmt.Sprintf("Speed      %.1f rows/s  %s/s  ETA %s",
+		rowsPerSec, formatBytes(bytesPerSec), p.eta(snapshot)))
+
+	lines = append(lines,
+		fmt.Sprintf("Downloads  active %d  ok %d  unchanged %d  failed %d  %s",
+			downloader.ActiveDownloads, downloader.DownloadSuccessful, downloader.Unchanged,
+			downloader.DownloadFailed, formatBytes(float64(downloader.BytesDownloaded))),
+		fmt.Sprintf("Writer     written %d  failed %d  queued %d  %s",
+			writer.WriteSuccess, writer.WriteFailed, writer.QueueDepth, formatBytes(float64(writer.BytesWritten))),
+	)
+	if guard := snapshot.Guard; guard != nil && guard.Paused {
+		lines = append(lines, fmt.Sprintf("Paused     low disk space, %s free", formatBytes(float64(guard.FreeBytes))))
+	}
+
+	for i, host := range BusiestHosts(snapshot.Hosts, TopHosts) {
+		label := ""
+		if i == 0 {
+			label = "Hosts"
+		}
+		stats := snapshot.Hosts[host]
+		lines = append(lines, fmt.Sprintf("%-10s %s  ok %d  failed %d  %s",
+			label, host, stats.Downloaded, stats.Failed, formatBytes(float64(stats.Bytes))))
+	}
+
+	for i, recent := range snapshot.RecentErrors {
+		label := ""
+		if i == 0 {
+			label = "Errors"
+		}
+		lines = append(lines, fmt.Sprintf("%-10s %s %s", label, recent.Time.Local().Format(time.TimeOnly), recent.Error))
+	}
+	return lines
+}
+
+func (p *progress) rowsLine(reader types.ReaderStats) string {
+	if reader.TotalURLs <= 0 {
+		return fmt.Sprintf("Rows       %d read", reader.URLsRead)
+	}
+
+	ratio := min(float64(reader.URLsRead)/float64(reader.TotalURLs), 1)
+	filled := int(ratio * barWidth)
+	bar := strings.Repeat("#", filled) + strings.Repeat("-", barWidth-filled)
+	return fmt.Sprintf("Rows       [%s] %5.1f%%  %d/%d", bar, ratio*100, reader.URLsRead, reader.TotalURLs)
+}
+
+// eta estimates the time left from the average rate at which rows were read since the start.
+func (p *progress) eta(snapshot types.Snapshot) string {
+	total, read := snapshot.Reader.TotalURLs, snapshot.Reader.URLsRead
+	elapsed := snapshot.Time.Sub(p.start.Time)
+	done := read - p.start.Reader.URLsRead
+	if total <= 0 || done <= 0 || elapsed <= 0 {
+		return "-"
+	}
+	if read >= total {
+		return "0s"
+	}
+
+	perRow := elapsed / time.Duration(done)
+	return (perRow * time.Duration(total-read)).Round(time.Second).String()
+}
+
+// BusiestHosts returns up to n hosts with the most downloads, including failed ones.
+func BusiestHosts(hosts map[string]types.HostStats, n int) []string {
+	names := make([]string, 0, len(hosts))
+	for host := range hosts {
+		names = append(names, host)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := hosts[names[i]], hosts[names[j]]
+		if a.Downloaded+a.Failed != b.Downloaded+b.Failed {
+			return a.Downloaded+a.Failed > b.Downloaded+b.Failed
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > n {
+		names = names[:n]
+	}
+	return names
+}
+
+// WorstHosts returns up to n hosts with failed downloads, the most failures first and, among hosts
+// failing as often, the highest share of failed requests first.
+func WorstHosts(hosts map[string]types.HostStats, n int) []string {
+	names := make([]string, 0, len(hosts))
+	for host, stats := range hosts {
+		if stats.Failed > 0 {
+			names = append(names, host)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := hosts[names[i]], hosts[names[j]]
+		if a.Failed != b.Failed {
+			return a.Failed > b.Failed
+		}
+		if rateA, rateB := FailureRate(a), FailureRate(b); rateA != rateB {
+			return rateA > rateB
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > n {
+		names = names[:n]
+	}
+	return names
+}
+
+// FailureRate returns the share of the host's requests that failed, between 0 and 1.
+func FailureRate(stats types.HostStats) float64 {
+	if stats.Requests == 0 {
+		return 0
+	}
+	return float64(stats.Failed) / float64(stats.Requests)
+}
+
+func formatBytes(size float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	i := 0
+	for size >= 1024 && i < len(units)-1 {
+		size /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", size, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", size, units[i])
+}
+
+// truncate cuts line to width and keeps it on a single line.
+func truncate(line string, width int) string {
+	line = strings.ReplaceAll(line, "\n", " ")
+	runes := []rune(line)
+	if len(runes) <= width {
+		return line
+	}
+	return string(runes[:width-3]) + "..."
+}
@@ -0,0 +1,121 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	var out bytes.Buffer
+	p := NewProgress(&out)
+
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	p.Render(types.Snapshot{Time: start, Reader: types.ReaderStats{URLsRead: 0, TotalURLs: 100}})
+	first := out.String()
+	assert.NotContains(t, first, "\033[")
+	assert.Contains(t, first, "[------------------------------]   0.0%  0/100")
+	assert.Contains(t, first, "ETA -")
+
+	out.Reset()
+	p.Render(types.Snapshot{
+		Time:       start.Add(10 * time.Second),
+		Reader:     types.ReaderStats{URLsRead: 25, TotalURLs: 100},
+		Downloader: types.DownloaderStats{ActiveDownloads: 3, DownloadSuccessful: 20, DownloadFailed: 2, BytesDownloaded: 10 << 20},
+		Writer:     types.WriterStats{WriteSuccess: 20, BytesWritten: 10 << 20},
+		Hosts: map[string]types.HostStats{
+			"a.example.com": {Downloaded: 5},
+			"b.example.com": {Downloaded: 15, Failed: 2, Bytes: 2048},
+		},
+		RecentErrors: []types.RecentError{{Time: start, URL: "https://a.example.com/x", Error: "bad response\nfrom server"}},
+	})
+	second := out.String()
+
+	// The previous four lines are cleared before drawing again.
+	assert.True(t, strings.HasPrefix(second, "\033[4F\033[J"))
+	assert.Contains(t, second, "[#######-----------------------]  25.0%  25/100")
+	assert.Contains(t, second, "2.5 rows/s  1.0 MiB/s  ETA 30s")
+	assert.Contains(t, second, "active 3  ok 20  unchanged 0  failed 2  10.0 MiB")
+	assert.Contains(t, second, "Hosts      b.example.com  ok 15  failed 2  2.0 KiB\n           a.example.com")
+	assert.Contains(t, second, "bad response from server")
+
+	// Finish leaves the view in place, so nothing is cleared afterwards.
+	out.Reset()
+	p.Finish(types.Snapshot{Time: start.Add(20 * time.Second), Reader: types.ReaderStats{URLsRead: 100, TotalURLs: 100}})
+	assert.Contains(t, out.String(), "ETA 0s")
+	out.Reset()
+	p.Render(types.Snapshot{Time: start.Add(21 * time.Second)})
+	assert.NotContains(t, out.String(), "\033[")
+}
+
+func TestRender_UnknownTotal(t *testing.T) {
+	var out bytes.Buffer
+	NewProgress(&out).Render(types.Snapshot{Time: time.Now(), Reader: types.ReaderStats{URLsRead: 7, TotalURLs: -1}})
+	assert.Contains(t, out.String(), "Rows       7 read\n")
+}
+
+func TestLogs(t *testing.T) {
+	// Logs and the view share a terminal, like stdout and stderr do.
+	var out bytes.Buffer
+	p := NewProgress(&out)
+	logs := p.Logs(&out)
+
+	// Before the view is drawn, logs pass through.
+	logs.Write([]byte("first\n"))
+	assert.Equal(t, "first\n", out.String())
+
+	p.Render(types.Snapshot{Time: time.Now(), Reader: types.ReaderStats{URLsRead: 7, TotalURLs: -1}})
+	view := strings.TrimPrefix(out.String(), "first\n")
+
+	// The view is cleared, the line logged and the view drawn again below it.
+	out.Reset()
+	logs.Write([]byte("second\n"))
+	assert.Equal(t, "\033[4F\033[J"+"second\n"+view, out.String())
+
+	// The next redraw only clears the view, not the logged line.
+	out.Reset()
+	p.Render(types.Snapshot{Time: time.Now()})
+	assert.True(t, strings.HasPrefix(out.String(), "\033[4F\033[J"+"Rows"))
+}
+
+func TestTopHosts(t *testing.T) {
+	hosts := map[string]types.HostStats{
+		"a": {Downloaded: 1},
+		"b": {Failed: 3},
+		"c": {Downloaded: 1},
+		"d": {Downloaded: 2},
+	}
+	assert.Equal(t, []string{"b", "d", "a"}, BusiestHosts(hosts, 3))
+	assert.Empty(t, BusiestHosts(nil, 3))
+}
+
+func TestWorstHosts(t *testing.T) {
+	hosts := map[string]types.HostStats{
+		"a": {Requests: 10, Downloaded: 10},
+		"b": {Requests: 10, Downloaded: 8, Failed: 2},
+		"c": {Requests: 2, Failed: 2},
+		"d": {Requests: 5, Downloaded: 1, Failed: 4},
+		"e": {Requests: 4, Downloaded: 3, Failed: 1},
+	}
+	assert.Equal(t, []string{"d", "c", "b"}, WorstHosts(hosts, 3))
+	assert.Equal(t, []string{"d", "c", "b", "e"}, WorstHosts(hosts, 10))
+	assert.Empty(t, WorstHosts(nil, 3))
+
+	assert.Equal(t, 0.8, FailureRate(hosts["d"]))
+	assert.Zero(t, FailureRate(types.HostStats{}))
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "3.0 GiB", formatBytes(3<<30))
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", truncate("short", 10))
+	assert.Equal(t, "abcdefg...", truncate("abcdefghijklmnop", 10))
+}
@@ -0,0 +1,33 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+// HostsReport is the content of the per-host report, the results of every host by its name.
+type HostsReport struct {
+	Time  time.Time                  `json:"time"`
+	Hosts map[string]types.HostStats `json:"hosts"`
+}
+
+// WriteHosts writes the host results of the snapshot to filePath as a single JSON document.
+func WriteHosts(filePath string, snapshot types.Snapshot) error {
+	hosts := snapshot.Hosts
+	if hosts == nil {
+		hosts = map[string]types.HostStats{}
+	}
+
+	data, err := json.MarshalIndent(HostsReport{Time: snapshot.Time, Hosts: hosts}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("caught err while encoding hosts report: %w", err)
+	}
+	if err := os.WriteFile(filePath, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("caught err while writing hosts report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package report
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteHosts(t *testing.T) {
+	hostsFile := filepath.Join(t.TempDir(), "hosts.json")
+	snapshot := types.Snapshot{
+		Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Hosts: map[string]types.HostStats{
+			"example.com": {Requests: 3, Downloaded: 2, Failed: 1, FailedReasons: map[string]int32{"http_5xx": 1}, Bytes: 10, AvgLatencyMs: 12.5},
+			"other.com":   {Requests: 1, Downloaded: 1, Bytes: 4},
+		},
+	}
+
+	assert.NoError(t, WriteHosts(hostsFile, snapshot))
+
+	content, err := os.ReadFile(hostsFile)
+	assert.NoError(t, err)
+	var got HostsReport
+	assert.NoError(t, json.Unmarshal(content, &got))
+	assert.Equal(t, HostsReport{Time: snapshot.Time, Hosts: snapshot.Hosts}, got)
+}
+
+func TestWriteHosts_Error(t *testing.T) {
+	hostsFile := filepath.Join(t.TempDir(), "missing", "hosts.json")
+	assert.Error(t, WriteHosts(hostsFile, types.Snapshot{}))
+}
@@ -0,0 +1,59 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+type reporter struct {
+	config  config.ReportConfig
+	logger  types.Logger
+	file    *os.File
+	encoder *json.Encoder
+	mu      sync.Mutex
+}
+
+// NewReporter creates the report file and returns a reporter writing one JSON line per entry to it.
+func NewReporter(config config.ReportConfig, logger types.Logger) (*reporter, error) {
+	file, err := os.Create(config.FilePath)
+	if err != nil {
+		return nil, fmt.Errorf("caught err while creating report file: %w", err)
+	}
+
+	rep := &reporter{
+		config:  config,
+		logger:  logger,
+		file:    file,
+		encoder: json.NewEncoder(file),
+	}
+
+	rep.logger.Infof("Reporter started, writing to %s", config.FilePath)
+	return rep, nil
+}
+
+// Record appends the entry to the report file.
+func (r *reporter) Record(entry types.ReportEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.encoder.Encode(entry); err != nil {
+		r.logger.Errorf("Failed to write report entry for %s: %s", entry.URL, err)
+	}
+}
+
+// Close flushes and closes the report file.
+func (r *reporter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.file.Sync(); err != nil {
+		r.file.Close()
+		return err
+	}
+	return r.file.Close()
+}
@@ -0,0 +1,59 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	reportFile := filepath.Join(t.TempDir(), "report.jsonl")
+
+	rep, err := NewReporter(config.ReportConfig{FilePath: reportFile}, types.NewLoggerStub())
+	assert.NoError(t, err)
+
+	entries := []types.ReportEntry{
+		{
+			URL:       "http://example.com",
+			FinalURL:  "https://example.com/",
+			Redirects: []string{"http://example.com"},
+			Status:    types.ReportStatusDownloaded,
+		},
+		{
+			URL:    "https://example.org",
+			Status: types.ReportStatusFailed,
+			Error:  "bad response",
+		},
+	}
+	for _, entry := range entries {
+		rep.Record(entry)
+	}
+	assert.NoError(t, rep.Close())
+
+	file, err := os.Open(reportFile)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	var got []types.ReportEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry types.ReportEntry
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &entry))
+		got = append(got, entry)
+	}
+	assert.Equal(t, entries, got)
+}
+
+func TestNewReporter_Error(t *testing.T) {
+	reportFile := filepath.Join(t.TempDir(), "missing", "report.jsonl")
+
+	rep, err := NewReporter(config.ReportConfig{FilePath: reportFile}, types.NewLoggerStub())
+	assert.Error(t, err)
+	assert.Nil(t, rep)
+}
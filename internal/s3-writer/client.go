@@ -0,0 +1,204 @@
+package s3writer
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// responseError is the error document S3 returns for failed requests.
+type responseError struct {
+	StatusCode int    `xml:"-"`
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *responseError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3 request failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("s3 request failed with status %d: %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+// client speaks the subset of the S3 API needed to upload objects.
+type client struct {
+	http      *http.Client
+	endpoint  *url.URL
+	bucket    string
+	pathStyle bool
+	partSize  int64
+	// timeout bounds every request, so a stalled service cannot block the writer workers.
+	timeout time.Duration
+	signer  *signer
+}
+
+// putObject uploads content under key, in parts when it is larger than a single part.
+func (c *client) putObject(ctx context.Context, key string, content []byte, contentType string) (bool, error) {
+	if int64(len(content)) <= c.partSize {
+		header := http.Header{}
+		if contentType != "" {
+			header.Set("Content-Type", contentType)
+		}
+		_, err := c.do(ctx, http.MethodPut, key, nil, header, content)
+		return false, err
+	}
+	return true, c.multipartUpload(ctx, key, content, contentType)
+}
+
+// multipartUpload uploads content in parts of partSize, aborting the upload if a part fails.
+func (c *client) multipartUpload(ctx context.Context, key string, content []byte, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := c.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, header, nil)
+	if err != nil {
+		return fmt.Errorf("caught err while starting multipart upload: %w", err)
+	}
+	var initiated struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.Unmarshal(resp, &initiated); err != nil || initiated.UploadID == "" {
+		return fmt.Errorf("invalid response to multipart upload start: %s", resp)
+	}
+	uploadID := initiated.UploadID
+
+	complete := completeMultipartUpload{}
+	for offset, part := int64(0), 1; offset < int64(len(content)); offset, part = offset+c.partSize, part+1 {
+		end := min(offset+c.partSize, int64(len(content)))
+		etag, err := c.uploadPart(ctx, key, uploadID, part, content[offset:end])
+		if err != nil {
+			c.abort(ctx, key, uploadID)
+			return fmt.Errorf("caught err while uploading part %d: %w", part, err)
+		}
+		complete.Parts = append(complete.Parts, completedPart{PartNumber: part, ETag: etag})
+	}
+
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		c.abort(ctx, key, uploadID)
+		return err
+	}
+	resp, err = c.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, http.Header{}, body)
+	if err == nil {
+		err = completeError(resp)
+	}
+	if err != nil {
+		c.abort(ctx, key, uploadID)
+		return fmt.Errorf("caught err while completing multipart upload: %w", err)
+	}
+	return nil
+}
+
+// completeError returns the error of a complete multipart upload request, which S3 may report in the
+// body of a 200 OK response once the parts are being combined.
+func completeError(resp []byte) error {
+	var root struct {
+		XMLName xml.Name
+	}
+	if err := xml.Unmarshal(resp, &root); err != nil || root.XMLName.Local != "Error" {
+		return nil
+	}
+	respErr := &responseError{StatusCode: http.StatusOK}
+	xml.Unmarshal(resp, respErr)
+	return respErr
+}
+
+func (c *client) uploadPart(ctx context.Context, key, uploadID string, part int, content []byte) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	query := url.Values{"partNumber": {strconv.Itoa(part)}, "uploadId": {uploadID}}
+	req, err := c.newRequest(ctx, http.MethodPut, key, query, http.Header{}, content)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+// abort discards the parts of a failed upload, which would otherwise be stored and billed. It is
+// sent even when ctx is canceled, still bounded by the request timeout.
+func (c *client) abort(ctx context.Context, key, uploadID string) {
+	c.do(context.WithoutCancel(ctx), http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, http.Header{}, nil)
+}
+
+// do sends a signed request and returns the response body.
+func (c *client) do(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	req, err := c.newRequest(ctx, method, key, query, header, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+func (c *client) send(req *http.Request) (*http.Response, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		respErr := &responseError{StatusCode: resp.StatusCode}
+		content, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		xml.Unmarshal(content, respErr)
+		return nil, respErr
+	}
+	return resp, nil
+}
+
+func (c *client) newRequest(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.objectURL(key, query), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("caught err while creating s3 request: %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	c.signer.sign(req, hashHex(body))
+	return req, nil
+}
+
+// objectURL addresses key in the bucket, either in the path or as a subdomain of the endpoint.
+func (c *client) objectURL(key string, query url.Values) string {
+	u := *c.endpoint
+	path := strings.TrimSuffix(u.Path, "/") + "/"
+	if c.pathStyle {
+		path += c.bucket + "/"
+	} else {
+		u.Host = c.bucket + "." + u.Host
+	}
+	u.Path = path + key
+	u.RawPath = uriEncode(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+	return u.String()
+}
@@ -0,0 +1,259 @@
+package s3writer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	filewriter "github.com/puruabhi/jfrog/home-assignment/internal/file-writer"
+	"github.com/puruabhi/jfrog/home-assignment/internal/latency"
+	"github.com/puruabhi/jfrog/home-assignment/internal/manifest"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+const (
+	DefaultRegion   = "us-east-1"
+	DefaultPartSize = 8 << 20
+	// DefaultRequestTimeout bounds every request when no timeout is configured.
+	DefaultRequestTimeout = 5 * time.Minute
+)
+
+var ErrMissingCredentials = errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+
+type s3Writer struct {
+	ctx       context.Context
+	config    config.WriteConfig
+	logger    types.Logger
+	reporter  types.Reportable
+	manifest  types.Manifestable
+	guard     types.Guardable
+	observer  types.Observable
+	tracer    types.Traceable
+	client    *client
+	writeChan chan *filewriter.Queued
+	closeOnce sync.Once
+	done      chan struct{}
+	workers   int
+	waits     filewriter.WaitStats
+	phases    latency.Phases
+	failures  failure.Counts
+
+	// stat variables
+	stats struct {
+		writeFailed      atomic.Int32
+		writing          atomic.Int32
+		writeSuccess     atomic.Int32
+		multipartUploads atomic.Int32
+		bytesUploaded    atomic.Int64
+	}
+}
+
+// NewS3Writer initializes a writer uploading every download to the configured bucket and starts its goroutine.
+func NewS3Writer(ctx context.Context, config config.WriteConfig, logger types.Logger, reporter types.Reportable, manifest types.Manifestable, guard types.Guardable, observer types.Observable, tracer types.Traceable) (*s3Writer, error) {
+	client, err := newClient(config.S3)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := &s3Writer{
+		ctx:       ctx,
+		config:    config,
+		logger:    logger,
+		reporter:  reporter,
+		manifest:  manifest,
+		guard:     guard,
+		observer:  observer,
+		tracer:    tracer,
+		client:    client,
+		writeChan: make(chan *filewriter.Queued, filewriter.QueueSize(config)),
+		workers:   filewriter.Concurrency(config),
+		done:      make(chan struct{}),
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < writer.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			writer.writer()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		writer.logger.Infof("S3 writer stopped\n")
+		close(writer.done)
+	}()
+
+	writer.logger.Infof("S3 writer started with %d workers: %s/%s", writer.workers, client.endpoint, config.S3.Bucket)
+	return writer, nil
+}
+
+// newClient builds the S3 client, filling in the defaults and reading the credentials from the environment.
+func newClient(config config.S3Config) (*client, error) {
+	accessKeyID, secretAccessKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKeyID == "" || secretAccessKey == "" {
+		return nil, ErrMissingCredentials
+	}
+
+	region := config.Region
+	if region == "" {
+		region = DefaultRegion
+	}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("caught err while parsing s3 endpoint: %w", err)
+	}
+	partSize := config.PartSize
+	if partSize == 0 {
+		partSize = DefaultPartSize
+	}
+	timeout := time.Duration(config.RequestTimeoutSeconds) * time.Second
+	if timeout == 0 {
+		timeout = DefaultRequestTimeout
+	}
+
+	return &client{
+		http:      &http.Client{},
+		endpoint:  endpointURL,
+		bucket:    config.Bucket,
+		pathStyle: config.PathStyle,
+		partSize:  partSize,
+		timeout:   timeout,
+		signer: &signer{
+			accessKeyID:     accessKeyID,
+			secretAccessKey: secretAccessKey,
+			sessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+			region:          region,
+			now:             time.Now,
+		},
+	}, nil
+}
+
+// writer listens for downloads on the writeChan and uploads them. Every worker of the pool runs one.
+func (w *s3Writer) writer() {
+	for {
+		select {
+		case queued, ok := <-w.writeChan:
+			if !ok {
+				return
+			}
+			w.observer.ObserveQueueWait(w.waits.Observe(queued))
+			job := filewriter.StartWrite(w.tracer, queued.Download)
+			start := time.Now()
+			err := w.write(queued.Download)
+			w.observer.ObserveWrite(time.Since(start))
+			filewriter.EndWrite(queued.Download, job, err)
+		case <-w.ctx.Done():
+			return
+		}
+	}
+}
+
+// write uploads the download under the configured prefix, records it in the manifest and reports the
+// outcome. A failed upload is counted by its error class and returned.
+func (w *s3Writer) write(download *types.Download) error {
+	w.stats.writing.Add(1)
+	defer w.stats.writing.Add(-1)
+
+	logger := w.logger.With(download.LogFields()...)
+	err := w.upload(download, logger)
+	if err != nil {
+		logger.Errorf("Failed to upload: %s", err)
+		w.stats.writeFailed.Add(1)
+		w.failures.Add(err)
+		types.FailSpan(download.Context(), err)
+	} else {
+		download.Written()
+	}
+	filewriter.ReportWrite(w.reporter, download, err)
+	return err
+}
+
+// upload puts the content of the download into the bucket and records it in the manifest.
+func (w *s3Writer) upload(download *types.Download, logger types.Logger) error {
+	name := filewriter.FileName(w.config.Naming, download, download.Content)
+	dir, err := filewriter.Layout(w.config, download, name, time.Now())
+	if err != nil {
+		return failure.Classify(failure.ClassWrite, err)
+	}
+	key := w.config.S3.Prefix + path.Join(dir, name)
+	contentType := ""
+	if download.Header != nil {
+		contentType = download.Header.Get("Content-Type")
+	}
+
+	if err := w.guard.Reserve(int64(len(download.Content))); err != nil {
+		return failure.Classify(failure.ClassDiskSpace, fmt.Errorf("caught err while reserving space: %w", err))
+	}
+
+	start := time.Now()
+	multipart, err := w.client.putObject(w.ctx, key, download.Content, contentType)
+	if err != nil {
+		w.guard.Release(int64(len(download.Content)))
+		return failure.Classify(failure.ClassUpload, err)
+	}
+	uploaded := time.Since(start)
+	w.phases.Observe(latency.HostOf(download.URL), latency.PhaseUpload, uploaded)
+	w.observer.ObservePhase(latency.PhaseUpload, uploaded)
+
+	logger.Debugf("Uploaded: %s", key)
+	w.stats.writeSuccess.Add(1)
+	w.stats.bytesUploaded.Add(int64(len(download.Content)))
+	if multipart {
+		w.stats.multipartUploads.Add(1)
+	}
+
+	entry := manifest.NewEntry(download, key)
+	if err := w.manifest.Append(entry); err != nil {
+		logger.Errorf("Failed to add %s to manifest: %s", key, err)
+	}
+	return nil
+}
+
+// PushForWrite sends the download to the writeChan for uploading.
+func (w *s3Writer) PushForWrite(download *types.Download) {
+	w.writeChan <- filewriter.NewQueued(download)
+}
+
+// Close stops accepting downloads and waits until the queued ones are uploaded.
+func (w *s3Writer) Close() error {
+	w.closeOnce.Do(func() {
+		close(w.writeChan)
+	})
+	<-w.done
+	return nil
+}
+
+func (w *s3Writer) GetStats() types.WriterStats {
+	return types.WriterStats{
+		Workers:          w.workers,
+		QueueDepth:       len(w.writeChan),
+		QueueWaitAvgMs:   w.waits.AvgMs(),
+		QueueWaitMaxMs:   w.waits.MaxMs(),
+		WriteFailed:      w.stats.writeFailed.Load(),
+		Writing:          w.stats.writing.Load(),
+		WriteSuccess:     w.stats.writeSuccess.Load(),
+		BytesWritten:     w.stats.bytesUploaded.Load(),
+		MultipartUploads: w.stats.multipartUploads.Load(),
+		FailedReasons:    w.failures.Get(),
+		Phases:           w.phases.Stats(),
+	}
+}
+
+// GetHostPhases returns the upload phase per host.
+func (w *s3Writer) GetHostPhases() map[string]map[string]types.PhaseStats {
+	return w.phases.HostStats()
+}
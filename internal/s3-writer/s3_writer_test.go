@@ -0,0 +1,270 @@
+package s3writer
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	typeMocks "github.com/puruabhi/jfrog/home-assignment/internal/types/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is an in-memory stand-in for the object and multipart upload calls of a path-style S3 service.
+type fakeS3 struct {
+	mu           sync.Mutex
+	objects      map[string][]byte
+	contentTypes map[string]string
+	uploads      map[string]map[int][]byte
+	aborted      int
+	failParts    bool
+	// failComplete answers complete multipart upload requests with 200 OK and an error document.
+	failComplete bool
+	unsignedReq  int
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		objects:      make(map[string][]byte),
+		contentTypes: make(map[string]string),
+		uploads:      make(map[string]map[int][]byte),
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), signingAlgorithm+" Credential=test-key/") ||
+		r.Header.Get("X-Amz-Content-Sha256") == "" {
+		f.unsignedReq++
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, "<Error><Code>AccessDenied</Code><Message>unsigned</Message></Error>")
+		return
+	}
+
+	key := r.URL.Path
+	body, _ := io.ReadAll(r.Body)
+	query := r.URL.Query()
+
+	switch {
+	case r.Method == http.MethodPut && query.Has("partNumber"):
+		if f.failParts {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		part, _ := strconv.Atoi(query.Get("partNumber"))
+		f.uploads[query.Get("uploadId")][part] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, part))
+	case r.Method == http.MethodPut:
+		f.objects[key] = body
+		f.contentTypes[key] = r.Header.Get("Content-Type")
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		uploadID := fmt.Sprintf("upload-%d", len(f.uploads)+1)
+		f.uploads[uploadID] = make(map[int][]byte)
+		f.contentTypes[key] = r.Header.Get("Content-Type")
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", uploadID)
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		if f.failComplete {
+			fmt.Fprint(w, "<Error><Code>InternalError</Code><Message>try again</Message></Error>")
+			return
+		}
+		var complete completeMultipartUpload
+		xml.Unmarshal(body, &complete)
+		parts := f.uploads[query.Get("uploadId")]
+		var content []byte
+		for _, part := range complete.Parts {
+			content = append(content, parts[part.PartNumber]...)
+		}
+		f.objects[key] = content
+		delete(f.uploads, query.Get("uploadId"))
+		fmt.Fprintf(w, "<CompleteMultipartUploadResult><Key>%s</Key></CompleteMultipartUploadResult>", key)
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(f.uploads, query.Get("uploadId"))
+		f.aborted++
+	default:
+		w.WriteHeader(http.StatusNotImplemented)
+	}
+}
+
+func (f *fakeS3) keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	keys := make([]string, 0, len(f.objects))
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newTestS3Config(endpoint string) config.WriteConfig {
+	return config.WriteConfig{
+		Naming: config.NamingSHA256,
+		S3: config.S3Config{
+			Endpoint:  endpoint,
+			Bucket:    "bucket",
+			Prefix:    "runs/1/",
+			PathStyle: true,
+			PartSize:  10,
+		},
+	}
+}
+
+func TestNewS3Writer_MissingCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+
+	writer, err := NewS3Writer(context.Background(), newTestS3Config("http://localhost"), types.NewLoggerStub(), types.NewReporterStub(), types.NewManifestStub(), types.NewGuardStub(), types.NewObserverStub(), types.NewTracerStub())
+	assert.ErrorIs(t, err, ErrMissingCredentials)
+	assert.Nil(t, writer)
+}
+
+func TestS3Writer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "test-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret")
+
+	fake := newFakeS3()
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	mockManifest := typeMocks.NewMockManifestable(ctrl)
+	var mu sync.Mutex
+	entries := make(map[string]types.ManifestEntry)
+	mockManifest.EXPECT().Append(gomock.Any()).DoAndReturn(func(e types.ManifestEntry) error {
+		mu.Lock()
+		defer mu.Unlock()
+		entries[e.URL] = e
+		return nil
+	}).Times(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	writer, err := NewS3Writer(ctx, newTestS3Config(server.URL), types.NewLoggerStub(), types.NewReporterStub(), mockManifest, types.NewGuardStub(), types.NewObserverStub(), types.NewTracerStub())
+	assert.NoError(t, err)
+
+	small := []byte("test data")
+	large := []byte(strings.Repeat("0123456789", 3) + "!")
+	writer.PushForWrite(&types.Download{URL: "https://example.com/a", Content: small, Header: http.Header{"Content-Type": {"text/plain"}}})
+	writer.PushForWrite(&types.Download{URL: "https://example.com/b", Content: large})
+	assert.NoError(t, writer.Close())
+
+	smallKey := "/bucket/runs/1/916f0027a575074ce72a331777c3478d6513f786a591bd892da1a577bf2335f9.txt"
+	assert.Len(t, fake.keys(), 2)
+	assert.Equal(t, small, fake.objects[smallKey])
+	assert.Equal(t, "text/plain", fake.contentTypes[smallKey])
+	assert.Equal(t, large, fake.objects["/bucket/runs/1/"+entries["https://example.com/b"].SHA256+".txt"])
+	assert.Empty(t, fake.uploads)
+	assert.Zero(t, fake.unsignedReq)
+
+	assert.Equal(t, "runs/1/916f0027a575074ce72a331777c3478d6513f786a591bd892da1a577bf2335f9.txt", entries["https://example.com/a"].File)
+	assert.Equal(t, int32(2), writer.stats.writeSuccess.Load())
+	assert.Equal(t, int32(1), writer.stats.multipartUploads.Load())
+	assert.Equal(t, int64(len(small)+len(large)), writer.stats.bytesUploaded.Load())
+	assert.Equal(t, int64(2), writer.GetStats().Phases["upload"].Count)
+	assert.Equal(t, int64(2), writer.GetHostPhases()["example.com"]["upload"].Count)
+}
+
+func TestS3Writer_AbortsFailedMultipartUpload(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret")
+
+	tests := []struct {
+		name         string
+		failParts    bool
+		failComplete bool
+	}{
+		{name: "part fails", failParts: true},
+		// Completing can fail after the 200 OK status was sent.
+		{name: "complete fails", failComplete: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := newFakeS3()
+			fake.failParts = test.failParts
+			fake.failComplete = test.failComplete
+			server := httptest.NewServer(fake)
+			defer server.Close()
+
+			writer, err := NewS3Writer(context.Background(), newTestS3Config(server.URL), types.NewLoggerStub(), types.NewReporterStub(), types.NewManifestStub(), types.NewGuardStub(), types.NewObserverStub(), types.NewTracerStub())
+			assert.NoError(t, err)
+
+			writer.PushForWrite(&types.Download{URL: "https://example.com/b", Content: []byte(strings.Repeat("x", 25))})
+			assert.NoError(t, writer.Close())
+
+			assert.Empty(t, fake.keys())
+			assert.Empty(t, fake.uploads)
+			assert.Equal(t, 1, fake.aborted)
+			assert.Equal(t, int32(1), writer.stats.writeFailed.Load())
+			assert.Equal(t, map[string]int32{failure.ClassUpload: 1}, writer.GetStats().FailedReasons)
+		})
+	}
+}
+
+func TestClient_RequestTimeout(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret")
+
+	// The service accepts the upload, then stalls on its parts.
+	var aborted atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Query().Has("uploads"):
+			fmt.Fprint(w, "<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>")
+		case r.Method == http.MethodDelete:
+			aborted.Add(1)
+		default:
+			<-release
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	c, err := newClient(newTestS3Config(server.URL).S3)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultRequestTimeout, c.timeout)
+	c.timeout = 50 * time.Millisecond
+
+	_, err = c.putObject(context.Background(), "small", []byte("test data"), "")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// A canceled writer still aborts the upload it leaves behind.
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err = c.putObject(ctx, "large", []byte(strings.Repeat("x", 25)), "")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, int32(1), aborted.Load())
+}
+
+func TestObjectURL(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "test-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test-secret")
+
+	c, err := newClient(config.S3Config{Bucket: "bucket", Region: "eu-west-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://bucket.s3.eu-west-1.amazonaws.com/a/b%20c.txt", c.objectURL("a/b c.txt", nil))
+
+	c, err = newClient(config.S3Config{Bucket: "bucket", Endpoint: "http://localhost:9000", PathStyle: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "http://localhost:9000/bucket/key?partNumber=1&uploadId=x", c.objectURL("key", map[string][]string{"uploadId": {"x"}, "partNumber": {"1"}}))
+}
@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ServiceName identifies the spans of this tool in the tracing backend.
+	ServiceName = "home-assignment"
+	tracerName  = "github.com/puruabhi/jfrog/home-assignment"
+	// shutdownTimeout bounds how long Close waits for the last spans to be exported.
+	shutdownTimeout = 10 * time.Second
+)
+
+type tracer struct {
+	config   config.TracingConfig
+	logger   types.Logger
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+	file     *os.File
+}
+
+// NewTracer creates a tracer exporting the spans in batches over OTLP/HTTP or to a JSON lines file.
+func NewTracer(ctx context.Context, cfg config.TracingConfig, logger types.Logger) (*tracer, error) {
+	t := &tracer{
+		config: cfg,
+		logger: logger,
+	}
+
+	exporter, err := t.newExporter(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("caught err while creating span exporter: %w", err)
+	}
+
+	t.provider = sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))),
+	)
+	t.tracer = t.provider.Tracer(tracerName)
+
+	t.logger.Infof("Tracing started, exporting spans to %s", t.destination())
+	return t, nil
+}
+
+func (t *tracer) newExporter(ctx context.Context) (sdktrace.SpanExporter, error) {
+	if t.config.Exporter == config.TracingExporterFile {
+		file, err := os.Create(t.config.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("caught err while creating trace file: %w", err)
+		}
+		t.file = file
+		return stdouttrace.New(stdouttrace.WithWriter(file))
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(t.config.Endpoint)}
+	if t.config.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	return otlptracehttp.New(ctx, opts...)
+}
+
+func (t *tracer) destination() string {
+	if t.file != nil {
+		return t.config.FilePath
+	}
+	return t.config.Endpoint
+}
+
+// Start starts a span as a child of the span in ctx.
+func (t *tracer) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, name, opts...)
+}
+
+// Close exports the buffered spans and closes the trace file. Spans that are still open are dropped.
+func (t *tracer) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	err := t.provider.Shutdown(ctx)
+	if err != nil {
+		err = fmt.Errorf("caught err while exporting spans: %w", err)
+	}
+	if t.file != nil {
+		err = errors.Join(err, t.file.Close())
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// exportedSpan holds the fields of a span written by the file exporter that the tests look at.
+type exportedSpan struct {
+	Name        string
+	SpanContext struct {
+		TraceID string
+		SpanID  string
+	}
+	Parent struct {
+		SpanID string
+	}
+	Attributes []struct {
+		Key string
+	}
+}
+
+func readSpans(t *testing.T, path string) map[string]exportedSpan {
+	file, err := os.Open(path)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	spans := make(map[string]exportedSpan)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var span exportedSpan
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &span))
+		spans[span.Name] = span
+	}
+	assert.NoError(t, scanner.Err())
+	return spans
+}
+
+func TestTracer_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	tracer, err := NewTracer(context.Background(), config.TracingConfig{Exporter: config.TracingExporterFile, FilePath: path}, types.NewLoggerStub())
+	assert.NoError(t, err)
+
+	ctx, job := tracer.Start(context.Background(), "job", trace.WithAttributes(attribute.Int("job.row", 1)))
+	_, download := tracer.Start(ctx, "download")
+	download.End()
+	job.End()
+	// Spans still open when the tracer is closed are not exported.
+	tracer.Start(ctx, "write")
+	assert.NoError(t, tracer.Close())
+
+	spans := readSpans(t, path)
+	assert.Len(t, spans, 2)
+	assert.Equal(t, spans["job"].SpanContext.TraceID, spans["download"].SpanContext.TraceID)
+	assert.Equal(t, spans["job"].SpanContext.SpanID, spans["download"].Parent.SpanID)
+	assert.Equal(t, "job.row", spans["job"].Attributes[0].Key)
+}
+
+func TestTracer_FileError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "spans.jsonl")
+	_, err := NewTracer(context.Background(), config.TracingConfig{Exporter: config.TracingExporterFile, FilePath: path}, types.NewLoggerStub())
+	assert.Error(t, err)
+}
+
+func TestTracer_OTLP(t *testing.T) {
+	var exports atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/traces" && r.Header.Get("Content-Type") == "application/x-protobuf" {
+			exports.Add(1)
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.TracingConfig{
+		Exporter: config.TracingExporterOTLP,
+		Endpoint: strings.TrimPrefix(server.URL, "http://"),
+		Insecure: true,
+	}
+	tracer, err := NewTracer(context.Background(), cfg, types.NewLoggerStub())
+	assert.NoError(t, err)
+
+	_, span := tracer.Start(context.Background(), "job")
+	span.End()
+	assert.NoError(t, tracer.Close())
+	assert.Equal(t, int32(1), exports.Load())
+}
@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+//go:generate mockgen -destination=./mocks/mock_cache.go -source=cache.go -package=mocks . Cacheable
+
+// CacheEntry holds the validators of the last successful download of a URL.
+type CacheEntry struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	SHA256       string    `json:"sha256"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type Cacheable interface {
+	Get(url string) (CacheEntry, bool)
+	Put(url string, entry CacheEntry)
+	Close() error
+}
+
+type cacheStub struct{}
+
+func NewCacheStub() *cacheStub {
+	return &cacheStub{}
+}
+
+func (c *cacheStub) Get(url string) (CacheEntry, bool) { return CacheEntry{}, false }
+func (c *cacheStub) Put(url string, entry CacheEntry)  {}
+func (c *cacheStub) Close() error                      { return nil }
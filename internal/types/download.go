@@ -0,0 +1,59 @@
+package types
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Download is the result of fetching a single URL.
+type Download struct {
+	// JobID and Row identify the job the download belongs to.
+	JobID string
+	Row   int
+	URL   string
+	// FinalURL is the URL the content was served from after following redirects.
+	FinalURL string
+	// Redirects lists the URLs visited before FinalURL, starting with URL.
+	Redirects  []string
+	StatusCode int
+	Header     http.Header
+	// NotModified is set when the server confirmed the cached copy is still current.
+	NotModified bool
+	Content     []byte
+	SHA256      string
+	// StartedAt and FinishedAt are the times the request was sent and the response body fully read.
+	StartedAt  time.Time
+	FinishedAt time.Time
+	// Phases holds how long each phase of the request took, keyed by name: dns, connect, tls,
+	// ttfb and transfer. Phases repeated across redirects are added up; reused connections skip
+	// dns, connect and tls.
+	Phases map[string]time.Duration
+	// Fields holds the other CSV columns of the row the URL came from.
+	Fields map[string]string
+	// Ctx carries the span of the job the download belongs to, and of the write while it is written.
+	Ctx context.Context
+	// OnWritten, when set, is called once the content is stored. The downloader caches the
+	// validators of the URL only then, so a failed write is downloaded again on the next run.
+	OnWritten func()
+}
+
+// Context returns the context carrying the span of the download, or the background context without one.
+func (d *Download) Context() context.Context {
+	if d.Ctx == nil {
+		return context.Background()
+	}
+	return d.Ctx
+}
+
+// Written tells the downloader that the content of the download is stored.
+func (d *Download) Written() {
+	if d.OnWritten != nil {
+		d.OnWritten()
+	}
+}
+
+// LogFields returns the fields identifying the job of the download on every log line about it.
+func (d *Download) LogFields() []any {
+	return logFields(d.Context(), d.JobID, d.Row, d.URL)
+}
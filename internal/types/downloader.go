@@ -4,6 +4,8 @@ package types
 
 type Downloadable interface {
 	GetFinishChan() chan struct{}
-	GetURLsChan() chan string
-	GetStats() any
+	GetURLsChan() chan *Job
+	GetStats() DownloaderStats
+	GetHostStats() map[string]HostStats
+	GetRecentErrors() []RecentError
 }
@@ -0,0 +1,8 @@
+package types
+
+//go:generate mockgen -destination=./mocks/mock_filter.go -source=filter.go -package=mocks . Filterable
+
+type Filterable interface {
+	GetURLsChan() chan *Job
+	GetStats() FilterStats
+}
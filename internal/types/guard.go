@@ -0,0 +1,33 @@
+package types
+
+import "context"
+
+//go:generate mockgen -destination=./mocks/mock_guard.go -source=guard.go -package=mocks . Guardable
+
+// Guardable protects the output from running out of space. Downloaders wait on it before
+// starting a download and writers reserve the bytes they are about to write.
+type Guardable interface {
+	// Wait blocks while downloads are paused and returns the stop reason once the run has to stop.
+	Wait(ctx context.Context) error
+	// Reserve accounts for size bytes about to be written, failing when they do not fit.
+	Reserve(size int64) error
+	// Release gives back size reserved bytes that were not written after all.
+	Release(size int64)
+	// StopReason returns why the run was stopped, or nil.
+	StopReason() error
+	GetStats() *GuardStats
+	Close()
+}
+
+type guardStub struct{}
+
+func NewGuardStub() *guardStub {
+	return &guardStub{}
+}
+
+func (g *guardStub) Wait(ctx context.Context) error { return nil }
+func (g *guardStub) Reserve(size int64) error       { return nil }
+func (g *guardStub) Release(size int64)             {}
+func (g *guardStub) StopReason() error              { return nil }
+func (g *guardStub) GetStats() *GuardStats          { return nil }
+func (g *guardStub) Close()                         {}
@@ -0,0 +1,42 @@
+package types
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Job is a single CSV row travelling through the pipeline.
+type Job struct {
+	// ID identifies the job on every log line about it.
+	ID string
+	// Row is the 1-based index of the row in the CSV, not counting the header.
+	Row int
+	URL string
+	// Fields holds the other columns of the row keyed by their lowercased header name.
+	Fields map[string]string
+	// Ctx carries the job span, which is ended by the stage the job ends in.
+	Ctx context.Context
+}
+
+// Context returns the context carrying the job span, or the background context without one.
+func (j *Job) Context() context.Context {
+	if j.Ctx == nil {
+		return context.Background()
+	}
+	return j.Ctx
+}
+
+// LogFields returns the fields identifying the job on every log line about it.
+func (j *Job) LogFields() []any {
+	return logFields(j.Context(), j.ID, j.Row, j.URL)
+}
+
+// logFields returns the job ID, row and URL, and the trace ID when the job is traced, as key-value pairs.
+func logFields(ctx context.Context, id string, row int, url string) []any {
+	fields := []any{"job_id", id, "row", row, "url", url}
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		fields = append(fields, "trace_id", span.TraceID().String())
+	}
+	return fields
+}
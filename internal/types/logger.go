@@ -7,6 +7,8 @@ type Logger interface {
 	Infof(format string, args ...any)
 	Warnf(format string, args ...any)
 	Errorf(format string, args ...any)
+	// With returns a logger adding the key-value pairs in fields to every line it logs.
+	With(fields ...any) Logger
 }
 
 type loggerStub struct{}
@@ -19,3 +21,4 @@ func (l *loggerStub) Debugf(format string, args ...any) {}
 func (l *loggerStub) Infof(format string, args ...any)  {}
 func (l *loggerStub) Warnf(format string, args ...any)  {}
 func (l *loggerStub) Errorf(format string, args ...any) {}
+func (l *loggerStub) With(fields ...any) Logger         { return l }
@@ -0,0 +1,29 @@
+package types
+
+import "time"
+
+//go:generate mockgen -destination=./mocks/mock_manifest.go -source=manifest.go -package=mocks . Manifestable
+
+// ManifestEntry describes a single download written to the output.
+type ManifestEntry struct {
+	URL       string    `json:"url"`
+	FinalURL  string    `json:"final_url,omitempty"`
+	File      string    `json:"file"`
+	Size      int       `json:"size"`
+	SHA256    string    `json:"sha256"`
+	WrittenAt time.Time `json:"written_at"`
+}
+
+type Manifestable interface {
+	Append(entry ManifestEntry) error
+	Close() error
+}
+
+type manifestStub struct{}
+
+func NewManifestStub() *manifestStub {
+	return &manifestStub{}
+}
+
+func (m *manifestStub) Append(entry ManifestEntry) error { return nil }
+func (m *manifestStub) Close() error                     { return nil }
@@ -0,0 +1,30 @@
+package types
+
+import "time"
+
+//go:generate mockgen -destination=./mocks/mock_observer.go -source=observer.go -package=mocks . Observable
+
+// Observable exports the pipeline stats and the latencies observed by downloaders and writers.
+type Observable interface {
+	// Register exports the stats of the snapshots returned by snapshot.
+	Register(snapshot func() Snapshot)
+	ObserveDownload(duration time.Duration)
+	ObserveQueueWait(duration time.Duration)
+	ObserveWrite(duration time.Duration)
+	// ObservePhase records the duration of one phase of a download or write, such as dns or fsync.
+	ObservePhase(phase string, duration time.Duration)
+	Close() error
+}
+
+type observerStub struct{}
+
+func NewObserverStub() *observerStub {
+	return &observerStub{}
+}
+
+func (o *observerStub) Register(snapshot func() Snapshot)                 {}
+func (o *observerStub) ObserveDownload(duration time.Duration)            {}
+func (o *observerStub) ObserveQueueWait(duration time.Duration)           {}
+func (o *observerStub) ObserveWrite(duration time.Duration)               {}
+func (o *observerStub) ObservePhase(phase string, duration time.Duration) {}
+func (o *observerStub) Close() error                                      { return nil }
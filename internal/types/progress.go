@@ -0,0 +1,11 @@
+package types
+
+//go:generate mockgen -destination=./mocks/mock_progress.go -source=progress.go -package=mocks . Progressable
+
+// Progressable shows the progress of the run on an interactive terminal.
+type Progressable interface {
+	// Render replaces the previous view with one of snapshot.
+	Render(snapshot Snapshot)
+	// Finish draws the final view and leaves it on the screen.
+	Finish(snapshot Snapshot)
+}
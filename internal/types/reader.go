@@ -5,4 +5,6 @@ package types
 type Readable interface {
 	Close() error
 	GetReadURLs() int32
+	// GetTotalURLs returns the number of URLs in the CSV, or -1 while they are still being counted.
+	GetTotalURLs() int32
 }
@@ -0,0 +1,43 @@
+package types
+
+//go:generate mockgen -destination=./mocks/mock_reporter.go -source=reporter.go -package=mocks . Reportable
+
+const (
+	ReportStatusDownloaded = "downloaded"
+	ReportStatusFailed     = "failed"
+	// ReportStatusWriteFailed marks URLs that were downloaded but could not be written.
+	ReportStatusWriteFailed = "write_failed"
+	ReportStatusUnchanged   = "unchanged"
+	ReportStatusFiltered    = "filtered"
+	ReportStatusInvalid     = "invalid"
+	ReportStatusDuplicate   = "duplicate"
+	// ReportStatusPreviouslyFetched marks URLs skipped because the manifest lists them.
+	ReportStatusPreviouslyFetched = "previously_fetched"
+)
+
+// ReportEntry describes what happened to a single URL.
+type ReportEntry struct {
+	Row       int      `json:"row,omitempty"`
+	URL       string   `json:"url"`
+	FinalURL  string   `json:"final_url,omitempty"`
+	Redirects []string `json:"redirects,omitempty"`
+	Status    string   `json:"status"`
+	Error     string   `json:"error,omitempty"`
+	// ErrorClass is the class of Error, such as dns, timeout, http_4xx or disk_space.
+	ErrorClass string `json:"error_class,omitempty"`
+	Rule       string `json:"rule,omitempty"`
+}
+
+type Reportable interface {
+	Record(entry ReportEntry)
+	Close() error
+}
+
+type reporterStub struct{}
+
+func NewReporterStub() *reporterStub {
+	return &reporterStub{}
+}
+
+func (r *reporterStub) Record(entry ReportEntry) {}
+func (r *reporterStub) Close() error             { return nil }
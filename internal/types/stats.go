@@ -0,0 +1,125 @@
+package types
+
+import "time"
+
+// Snapshot holds the stats of every stage of the pipeline, read at Time.
+//
+// Stats of a stage that go up and down are tagged metric:"gauge". The metrics endpoint exports
+// all other numeric stats as counters.
+type Snapshot struct {
+	Time       time.Time       `json:"time"`
+	Reader     ReaderStats     `json:"csv_reader"`
+	Filter     FilterStats     `json:"url_filter"`
+	Downloader DownloaderStats `json:"downloader"`
+	Writer     WriterStats     `json:"writer"`
+	// Guard is nil when neither a low-water mark nor an output quota is configured.
+	Guard        *GuardStats          `json:"disk_guard,omitempty"`
+	Hosts        map[string]HostStats `json:"hosts,omitempty"`
+	RecentErrors []RecentError        `json:"recent_errors,omitempty"`
+}
+
+type ReaderStats struct {
+	URLsRead int32 `json:"urls_read"`
+	// TotalURLs is the number of rows in the CSV, -1 until they are counted.
+	TotalURLs int32 `json:"total_urls" metric:"gauge"`
+}
+
+type FilterStats struct {
+	Passed   int32 `json:"passed"`
+	Filtered int32 `json:"filtered"`
+	Invalid  int32 `json:"invalid"`
+	// InvalidReasons counts the invalid URLs by the reason they were rejected for.
+	InvalidReasons    map[string]int32 `json:"invalid_reasons"`
+	Duplicates        int32            `json:"duplicates"`
+	PreviouslyFetched int32            `json:"previously_fetched"`
+}
+
+type DownloaderStats struct {
+	ActiveDownloads    int32 `json:"active_downloads" metric:"gauge"`
+	DownloadSuccessful int32 `json:"download_successful"`
+	DownloadFailed     int32 `json:"download_failed"`
+	Redirected         int32 `json:"redirected"`
+	RedirectBlocked    int32 `json:"redirect_blocked"`
+	DestinationBlocked int32 `json:"destination_blocked"`
+	Unchanged          int32 `json:"unchanged"`
+	TooLarge           int32 `json:"too_large"`
+	QuotaExceeded      int32 `json:"quota_exceeded"`
+	ContentTypeDenied  int32 `json:"content_type_denied"`
+	BytesDownloaded    int64 `json:"bytes_downloaded"`
+	// FailedReasons counts the failed downloads by their error class, such as dns, timeout or http_5xx.
+	FailedReasons map[string]int32 `json:"failed_reasons,omitempty"`
+	// Phases summarizes the request phases of all downloads.
+	Phases map[string]PhaseStats `json:"phases,omitempty"`
+}
+
+// WriterStats are shared by the file, archive and S3 writers. Stats a writer does not keep stay zero.
+type WriterStats struct {
+	Workers        int     `json:"workers" metric:"gauge"`
+	QueueDepth     int     `json:"queue_depth" metric:"gauge"`
+	QueueWaitAvgMs float64 `json:"queue_wait_avg_ms" metric:"gauge"`
+	QueueWaitMaxMs float64 `json:"queue_wait_max_ms" metric:"gauge"`
+	WriteFailed    int32   `json:"write_failed"`
+	Writing        int32   `json:"writing" metric:"gauge"`
+	WriteSuccess   int32   `json:"write_success"`
+	BytesWritten   int64   `json:"bytes_written"`
+
+	Decoded      int32 `json:"decoded"`
+	Decompressed int32 `json:"decompressed"`
+	Extracted    int32 `json:"extracted"`
+	Sidecars     int32 `json:"sidecars"`
+
+	ExistingSkipped     int32 `json:"existing_skipped"`
+	ExistingOverwritten int32 `json:"existing_overwritten"`
+	ExistingRenamed     int32 `json:"existing_renamed"`
+	ExistingKept        int32 `json:"existing_kept"`
+
+	MultipartUploads int32 `json:"multipart_uploads"`
+
+	// FailedReasons counts the failed writes by their error class, such as disk_space or write_error.
+	FailedReasons map[string]int32 `json:"failed_reasons,omitempty"`
+
+	// Phases summarizes the write phases of all writes.
+	Phases map[string]PhaseStats `json:"phases,omitempty"`
+}
+
+// HostStats are the download results for a single host of the URLs in the CSV.
+type HostStats struct {
+	// Requests counts every URL of the host, Downloaded the ones that succeeded, unchanged
+	// ones included, and Failed the rest.
+	Requests   int32 `json:"requests"`
+	Downloaded int32 `json:"downloaded"`
+	Failed     int32 `json:"failed"`
+	// FailedReasons counts the failures by error class.
+	FailedReasons map[string]int32 `json:"failed_reasons,omitempty"`
+	Bytes         int64            `json:"bytes"`
+	// AvgLatencyMs is the average time taken by a request to the host, failed ones included.
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+	// Phases summarizes the download and write phases of the host's URLs.
+	Phases map[string]PhaseStats `json:"phases,omitempty"`
+}
+
+// PhaseStats summarize the durations of one phase of downloading or writing, in milliseconds.
+// Percentiles are estimated from a histogram and are accurate to about 20%.
+type PhaseStats struct {
+	Count int64   `json:"count"`
+	AvgMs float64 `json:"avg_ms"`
+	P50Ms float64 `json:"p50_ms"`
+	P90Ms float64 `json:"p90_ms"`
+	P99Ms float64 `json:"p99_ms"`
+	MaxMs float64 `json:"max_ms"`
+}
+
+// RecentError is one of the latest download failures.
+type RecentError struct {
+	Time  time.Time `json:"time"`
+	URL   string    `json:"url"`
+	Error string    `json:"error"`
+}
+
+type GuardStats struct {
+	Paused       bool    `json:"paused" metric:"gauge"`
+	Pauses       int32   `json:"pauses"`
+	PausedSec    float64 `json:"paused_sec"`
+	FreeBytes    int64   `json:"free_bytes" metric:"gauge"`
+	WrittenBytes int64   `json:"written_bytes" metric:"gauge"`
+}
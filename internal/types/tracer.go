@@ -0,0 +1,51 @@
+package types
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+//go:generate mockgen -destination=./mocks/mock_tracer.go -source=tracer.go -package=mocks . Traceable
+
+// Traceable starts the spans following a job through the pipeline. The reader starts a job span
+// per row, the downloader and writer add child spans, and the stage a job ends in ends its span.
+type Traceable interface {
+	// Start starts a span as a child of the span in ctx, returning a context carrying the new span.
+	Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span)
+	// Close exports the spans still buffered.
+	Close() error
+}
+
+type tracerStub struct{}
+
+func NewTracerStub() *tracerStub {
+	return &tracerStub{}
+}
+
+func (t *tracerStub) Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return ctx, noop.Span{}
+}
+func (t *tracerStub) Close() error { return nil }
+
+// FailSpan marks the span in ctx as failed with err.
+func FailSpan(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// EndJob ends the job span in ctx. Status is the report status the job ended with, if known.
+func EndJob(ctx context.Context, status string, err error) {
+	span := trace.SpanFromContext(ctx)
+	if status != "" {
+		span.SetAttributes(attribute.String("job.status", status))
+	}
+	if err != nil {
+		FailSpan(ctx, err)
+	}
+	span.End()
+}
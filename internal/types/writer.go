@@ -3,6 +3,9 @@ package types
 //go:generate mockgen -destination=./mocks/mock_writer.go -source=writer.go -package=mocks . Writable
 
 type Writable interface {
-	PushForWrite([]byte)
-	GetStats() any
+	PushForWrite(download *Download)
+	GetStats() WriterStats
+	// GetHostPhases summarizes the write phases per host of the downloaded URLs.
+	GetHostPhases() map[string]map[string]PhaseStats
+	Close() error
 }
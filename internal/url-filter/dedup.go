@@ -0,0 +1,141 @@
+package urlfilter
+
+import (
+	"hash/fnv"
+	"math"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/manifest"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+const (
+	DefaultMaxMemoryEntries  = 1_000_000
+	DefaultExpectedEntries   = 10_000_000
+	DefaultFalsePositiveRate = 0.0001
+)
+
+// seenIndex remembers URLs in an exact set and switches to a bloom filter once the set
+// reaches its limit, trading a small false positive rate for bounded memory.
+type seenIndex struct {
+	config config.DedupConfig
+	set    map[string]struct{}
+	bloom  *bloomFilter
+}
+
+func newSeenIndex(config config.DedupConfig) *seenIndex {
+	if config.MaxMemoryEntries == 0 {
+		config.MaxMemoryEntries = DefaultMaxMemoryEntries
+	}
+	if config.ExpectedEntries == 0 {
+		config.ExpectedEntries = DefaultExpectedEntries
+	}
+	if config.FalsePositiveRate == 0 {
+		config.FalsePositiveRate = DefaultFalsePositiveRate
+	}
+
+	return &seenIndex{
+		config: config,
+		set:    make(map[string]struct{}),
+	}
+}
+
+// add records url and reports whether it had been seen before.
+func (s *seenIndex) add(url string) bool {
+	if s.bloom != nil {
+		return s.bloom.add(url)
+	}
+
+	if _, ok := s.set[url]; ok {
+		return true
+	}
+	s.set[url] = struct{}{}
+
+	if len(s.set) >= s.config.MaxMemoryEntries {
+		s.switchToBloom()
+	}
+	return false
+}
+
+// contains reports whether url has been added before.
+func (s *seenIndex) contains(url string) bool {
+	if s.bloom != nil {
+		return s.bloom.contains(url)
+	}
+
+	_, ok := s.set[url]
+	return ok
+}
+
+func (s *seenIndex) switchToBloom() {
+	s.bloom = newBloomFilter(max(s.config.ExpectedEntries, len(s.set)), s.config.FalsePositiveRate)
+	for url := range s.set {
+		s.bloom.add(url)
+	}
+	s.set = nil
+}
+
+// loadManifest returns an index of the URLs written by previous runs.
+func loadManifest(config config.DedupConfig) (*seenIndex, error) {
+	index := newSeenIndex(config)
+	err := manifest.Read(config.ManifestFile, func(entry types.ManifestEntry) error {
+		index.add(entry.URL)
+		return nil
+	})
+	return index, err
+}
+
+type bloomFilter struct {
+	bits   []uint64
+	size   uint64
+	hashes uint64
+}
+
+// newBloomFilter sizes a bloom filter for n entries at the given false positive rate.
+func newBloomFilter(n int, falsePositiveRate float64) *bloomFilter {
+	size := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	size = max(size, 64)
+	hashes := uint64(math.Max(1, math.Round(float64(size)/float64(n)*math.Ln2)))
+
+	return &bloomFilter{
+		bits:   make([]uint64, (size+63)/64),
+		size:   size,
+		hashes: hashes,
+	}
+}
+
+// add sets the bits of s and reports whether all of them were already set.
+func (b *bloomFilter) add(s string) bool {
+	seen := true
+	b.forEachBit(s, func(word int, mask uint64) {
+		if b.bits[word]&mask == 0 {
+			seen = false
+			b.bits[word] |= mask
+		}
+	})
+	return seen
+}
+
+// contains reports whether all bits of s are set.
+func (b *bloomFilter) contains(s string) bool {
+	seen := true
+	b.forEachBit(s, func(word int, mask uint64) {
+		if b.bits[word]&mask == 0 {
+			seen = false
+		}
+	})
+	return seen
+}
+
+// forEachBit derives the bit positions of s with double hashing.
+func (b *bloomFilter) forEachBit(s string, fn func(word int, mask uint64)) {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	sum := h.Sum64()
+	h1, h2 := sum&math.MaxUint32, sum>>32|1
+
+	for i := uint64(0); i < b.hashes; i++ {
+		bit := (h1 + i*h2) % b.size
+		fn(int(bit/64), uint64(1)<<(bit%64))
+	}
+}
@@ -0,0 +1,97 @@
+package urlfilter
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/manifest"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeenIndex(t *testing.T) {
+	index := newSeenIndex(config.DedupConfig{})
+
+	assert.False(t, index.add("https://example.com/"))
+	assert.True(t, index.add("https://example.com/"))
+	assert.False(t, index.add("https://example.com/other"))
+	assert.True(t, index.contains("https://example.com/other"))
+	assert.False(t, index.contains("https://example.org/"))
+	assert.Nil(t, index.bloom)
+}
+
+func TestSeenIndex_SwitchToBloom(t *testing.T) {
+	index := newSeenIndex(config.DedupConfig{
+		MaxMemoryEntries:  10,
+		ExpectedEntries:   1000,
+		FalsePositiveRate: 0.001,
+	})
+
+	for i := 0; i < 10; i++ {
+		assert.False(t, index.add(fmt.Sprintf("https://example.com/%d", i)))
+	}
+	assert.NotNil(t, index.bloom)
+	assert.Nil(t, index.set)
+
+	for i := 0; i < 10; i++ {
+		assert.True(t, index.add(fmt.Sprintf("https://example.com/%d", i)))
+	}
+
+	falsePositives := 0
+	for i := 10; i < 1000; i++ {
+		if index.add(fmt.Sprintf("https://example.com/%d", i)) {
+			falsePositives++
+		}
+	}
+	assert.LessOrEqual(t, falsePositives, 10)
+}
+
+func TestFilter_Dedup(t *testing.T) {
+	manifestFile := filepath.Join(t.TempDir(), config.ManifestFileName)
+	previousRun, err := manifest.NewManifest(manifestFile)
+	assert.NoError(t, err)
+	assert.NoError(t, previousRun.Append(types.ManifestEntry{URL: "https://www.google.com/"}))
+	assert.NoError(t, previousRun.Close())
+
+	out := make(chan *types.Job, 10)
+	filter, err := NewURLFilter(context.Background(), config.FilterConfig{
+		Dedup: config.DedupConfig{Mode: config.DedupModeManifest, ManifestFile: manifestFile},
+	}, types.NewLoggerStub(), types.NewReporterStub(), out)
+	assert.NoError(t, err)
+
+	filter.GetURLsChan() <- &types.Job{Row: 1, URL: "www.example.com"}
+	filter.GetURLsChan() <- &types.Job{Row: 2, URL: "HTTPS://www.example.com:443/"}
+	filter.GetURLsChan() <- &types.Job{Row: 3, URL: "www.google.com"}
+	filter.GetURLsChan() <- &types.Job{Row: 4, URL: "www.example.com/other"}
+	close(filter.GetURLsChan())
+
+	var urls []string
+	for job := range out {
+		urls = append(urls, job.URL)
+	}
+
+	assert.Equal(t, []string{"https://www.example.com/", "https://www.example.com/other"}, urls)
+	assert.Equal(t, int32(1), filter.stats.duplicates.Load())
+	assert.Equal(t, int32(1), filter.stats.previouslyFetched.Load())
+}
+
+func TestFilter_DedupDisabled(t *testing.T) {
+	out := make(chan *types.Job, 10)
+	filter, err := NewURLFilter(context.Background(), config.FilterConfig{
+		Dedup: config.DedupConfig{Mode: config.DedupModeNone},
+	}, types.NewLoggerStub(), types.NewReporterStub(), out)
+	assert.NoError(t, err)
+
+	filter.GetURLsChan() <- &types.Job{Row: 1, URL: "www.example.com"}
+	filter.GetURLsChan() <- &types.Job{Row: 2, URL: "www.example.com"}
+	close(filter.GetURLsChan())
+
+	count := 0
+	for range out {
+		count++
+	}
+	assert.Equal(t, 2, count)
+}
@@ -0,0 +1,170 @@
+package urlfilter
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+const DefaultScheme = "https"
+
+var (
+	ErrEmptyURL          = errors.New("empty URL")
+	ErrUnparseableURL    = errors.New("unparseable URL")
+	ErrUnsupportedScheme = errors.New("unsupported scheme")
+	ErrMissingHost       = errors.New("missing host")
+	ErrInvalidHost       = errors.New("invalid host")
+	ErrInvalidPort       = errors.New("invalid port")
+)
+
+// ReasonMalformed groups invalid URLs failing for any other reason than the validation errors, so
+// the number of reasons stays bounded.
+const ReasonMalformed = "malformed"
+
+var validationErrors = []error{
+	ErrEmptyURL,
+	ErrUnparseableURL,
+	ErrUnsupportedScheme,
+	ErrMissingHost,
+	ErrInvalidHost,
+	ErrInvalidPort,
+}
+
+var (
+	// strayPercent matches a '%' that does not start a valid escape sequence.
+	strayPercent = regexp.MustCompile(`%([^0-9A-Fa-f]|[0-9A-Fa-f][^0-9A-Fa-f]|[0-9A-Fa-f]?$)`)
+	lowerEscape  = regexp.MustCompile(`%[0-9a-f][0-9A-Fa-f]|%[0-9A-F][0-9a-f]`)
+)
+
+// Normalize validates rawURL and returns it in canonical form, so that equivalent
+// spellings of the same URL compare equal. Schemeless URLs are assumed to be https.
+func Normalize(rawURL string) (string, error) {
+	u, err := normalizeURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func normalizeURL(rawURL string) (*url.URL, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return nil, ErrEmptyURL
+	}
+
+	if strings.HasPrefix(rawURL, "//") {
+		rawURL = DefaultScheme + ":" + rawURL
+	} else if !strings.Contains(rawURL, "://") {
+		rawURL = DefaultScheme + "://" + rawURL
+	}
+
+	rawURL = fixEscapes(rawURL)
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnparseableURL, err)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if _, ok := defaultPorts[u.Scheme]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedScheme, u.Scheme)
+	}
+
+	host, err := normalizeHost(u.Hostname())
+	if err != nil {
+		return nil, err
+	}
+
+	port, err := normalizePort(u.Scheme, u.Port())
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host = host + ":" + port
+	}
+	u.Host = host
+
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.RawQuery = escapeInvalid(u.RawQuery)
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return u, nil
+}
+
+// invalidReason returns the short reason for a Normalize error, used to group invalid rows in stats.
+func invalidReason(err error) string {
+	for _, reason := range validationErrors {
+		if errors.Is(err, reason) {
+			return reason.Error()
+		}
+	}
+	return ReasonMalformed
+}
+
+// normalizeHost lowercases the host and converts internationalized names to punycode.
+func normalizeHost(host string) (string, error) {
+	host = strings.TrimSuffix(host, ".")
+	if host == "" {
+		return "", ErrMissingHost
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String(), nil
+	}
+
+	ascii, err := idna.Lookup.ToASCII(host)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrInvalidHost, err)
+	}
+	return strings.ToLower(ascii), nil
+}
+
+// normalizePort validates the port and drops it when it is the default for the scheme.
+func normalizePort(scheme, port string) (string, error) {
+	if port == "" {
+		return "", nil
+	}
+
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return "", fmt.Errorf("%w: %s", ErrInvalidPort, port)
+	}
+	if n == defaultPorts[scheme] {
+		return "", nil
+	}
+	return strconv.Itoa(n), nil
+}
+
+// fixEscapes encodes stray '%' characters and uppercases the hex digits of valid escapes.
+func fixEscapes(rawURL string) string {
+	for strayPercent.MatchString(rawURL) {
+		rawURL = strayPercent.ReplaceAllString(rawURL, "%25$1")
+	}
+	return lowerEscape.ReplaceAllStringFunc(rawURL, strings.ToUpper)
+}
+
+// escapeInvalid percent-encodes bytes that may not appear unescaped in a URL.
+func escapeInvalid(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`"<>\^`+"`{|}", c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
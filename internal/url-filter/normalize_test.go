@@ -0,0 +1,68 @@
+package urlfilter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"example.com", "https://example.com/"},
+		{"http://example.com", "http://example.com/"},
+		{"https://example.com", "https://example.com/"},
+		{"httpbin.org/get", "https://httpbin.org/get"},
+		{"  www.example.com/a  ", "https://www.example.com/a"},
+		{"//example.com/a", "https://example.com/a"},
+		{"HTTPS://WWW.Example.COM/Path", "https://www.example.com/Path"},
+		{"http://example.com:80/a", "http://example.com/a"},
+		{"https://example.com:443/a", "https://example.com/a"},
+		{"https://example.com:8443/a", "https://example.com:8443/a"},
+		{"http://example.com./a", "http://example.com/a"},
+		{"bücher.example/straße", "https://xn--bcher-kva.example/stra%C3%9Fe"},
+		{"example.com/a b?q=x y", "https://example.com/a%20b?q=x%20y"},
+		{"example.com/100%/done", "https://example.com/100%25/done"},
+		{"example.com/a%2fb", "https://example.com/a%2Fb"},
+		{"example.com/page#section", "https://example.com/page"},
+		{"http://[::1]:8080/a", "http://[::1]:8080/a"},
+	}
+
+	for _, test := range tests {
+		result, err := Normalize(test.input)
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.expected, result, test.input)
+	}
+}
+
+func TestNormalize_Invalid(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected error
+	}{
+		{"", ErrEmptyURL},
+		{"   ", ErrEmptyURL},
+		{"ftp://example.com/file", ErrUnsupportedScheme},
+		{"mailto://someone@example.com", ErrUnsupportedScheme},
+		{"https:///path-only", ErrMissingHost},
+		{"https://example.com:99999/", ErrInvalidPort},
+		{"https://exa mple.com/", ErrUnparseableURL},
+		{"https://-invalid-.example..com/", ErrInvalidHost},
+	}
+
+	for _, test := range tests {
+		_, err := Normalize(test.input)
+		assert.ErrorIs(t, err, test.expected, test.input)
+	}
+}
+
+func TestInvalidReason(t *testing.T) {
+	_, err := Normalize("https://example.com:99999/")
+	assert.Equal(t, "invalid port", invalidReason(err))
+
+	// Unexpected errors are grouped, not counted by their message.
+	assert.Equal(t, ReasonMalformed, invalidReason(errors.New("parse \"https://a\": unexpected")))
+}
@@ -0,0 +1,136 @@
+package urlfilter
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+)
+
+var defaultPorts = map[string]int{
+	"http":  80,
+	"https": 443,
+}
+
+// ruleSet holds the compiled filter rules.
+type ruleSet struct {
+	allowHosts    []string
+	denyHosts     []string
+	allowPatterns []*regexp.Regexp
+	denyPatterns  []*regexp.Regexp
+	allowSchemes  map[string]struct{}
+	allowPorts    map[int]struct{}
+}
+
+func newRuleSet(config config.FilterConfig) (*ruleSet, error) {
+	rules := &ruleSet{
+		allowHosts:   normalizeHosts(config.AllowHosts),
+		denyHosts:    normalizeHosts(config.DenyHosts),
+		allowSchemes: make(map[string]struct{}, len(config.AllowSchemes)),
+		allowPorts:   make(map[int]struct{}, len(config.AllowPorts)),
+	}
+
+	var err error
+	if rules.allowPatterns, err = compilePatterns(config.AllowPatterns); err != nil {
+		return nil, err
+	}
+	if rules.denyPatterns, err = compilePatterns(config.DenyPatterns); err != nil {
+		return nil, err
+	}
+
+	for _, scheme := range config.AllowSchemes {
+		rules.allowSchemes[strings.ToLower(scheme)] = struct{}{}
+	}
+	for _, port := range config.AllowPorts {
+		rules.allowPorts[port] = struct{}{}
+	}
+
+	return rules, nil
+}
+
+func normalizeHosts(hosts []string) []string {
+	normalized := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		normalized = append(normalized, strings.TrimPrefix(strings.ToLower(host), "."))
+	}
+	return normalized
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// match returns the rule rejecting u, or an empty string if u passes all rules.
+func (r *ruleSet) match(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	full := u.String()
+
+	if suffix, ok := matchHost(host, r.denyHosts); ok {
+		return "denyHosts:" + suffix
+	}
+	for _, re := range r.denyPatterns {
+		if re.MatchString(full) {
+			return "denyPatterns:" + re.String()
+		}
+	}
+
+	if len(r.allowSchemes) > 0 {
+		if _, ok := r.allowSchemes[strings.ToLower(u.Scheme)]; !ok {
+			return "allowSchemes"
+		}
+	}
+	if len(r.allowPorts) > 0 {
+		if _, ok := r.allowPorts[port(u)]; !ok {
+			return "allowPorts"
+		}
+	}
+	if len(r.allowHosts) > 0 {
+		if _, ok := matchHost(host, r.allowHosts); !ok {
+			return "allowHosts"
+		}
+	}
+	if len(r.allowPatterns) > 0 && !matchAny(full, r.allowPatterns) {
+		return "allowPatterns"
+	}
+
+	return ""
+}
+
+// matchHost returns the first suffix equal to host or to one of its parent domains.
+func matchHost(host string, suffixes []string) (string, bool) {
+	for _, suffix := range suffixes {
+		if host == suffix || strings.HasSuffix(host, "."+suffix) {
+			return suffix, true
+		}
+	}
+	return "", false
+}
+
+func matchAny(s string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// port returns the explicit port of u or the default one for its scheme.
+func port(u *url.URL) int {
+	if p := u.Port(); p != "" {
+		n, _ := strconv.Atoi(p)
+		return n
+	}
+	return defaultPorts[strings.ToLower(u.Scheme)]
+}
@@ -0,0 +1,209 @@
+package urlfilter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+)
+
+type urlFilter struct {
+	ctx      context.Context
+	config   config.FilterConfig
+	logger   types.Logger
+	reporter types.Reportable
+	rules    *ruleSet
+	seen     *seenIndex
+	previous *seenIndex
+	in       chan *types.Job
+	out      chan *types.Job
+
+	stats struct {
+		passed   atomic.Int32
+		filtered atomic.Int32
+		invalid  atomic.Int32
+
+		duplicates        atomic.Int32
+		previouslyFetched atomic.Int32
+
+		mu             sync.Mutex
+		invalidReasons map[string]int32
+	}
+}
+
+// NewURLFilter initializes a new urlFilter forwarding the accepted jobs to out and starts filtering.
+func NewURLFilter(ctx context.Context, config config.FilterConfig, logger types.Logger, reporter types.Reportable, out chan *types.Job) (*urlFilter, error) {
+	rules, err := newRuleSet(config)
+	if err != nil {
+		return nil, fmt.Errorf("caught err while building url filter: %w", err)
+	}
+
+	filter := &urlFilter{
+		ctx:      ctx,
+		config:   config,
+		logger:   logger,
+		reporter: reporter,
+		rules:    rules,
+		in:       make(chan *types.Job),
+		out:      out,
+	}
+
+	if err := filter.setupDedup(); err != nil {
+		return nil, fmt.Errorf("caught err while building url filter: %w", err)
+	}
+	filter.stats.invalidReasons = make(map[string]int32)
+
+	go filter.filter()
+
+	filter.logger.Infof("URL filter started")
+	return filter, nil
+}
+
+// filter normalizes the URL of every job, forwards the ones passing the rules and reports
+// the rejected ones until the input is closed.
+func (f *urlFilter) filter() {
+	defer close(f.out)
+
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case job, ok := <-f.in:
+			if !ok {
+				return
+			}
+
+			if f.accept(job) {
+				f.stats.passed.Add(1)
+				f.out <- job
+			}
+		}
+	}
+}
+
+// accept replaces the job URL with its normalized form and reports whether it should be downloaded.
+func (f *urlFilter) accept(job *types.Job) bool {
+	u, err := normalizeURL(job.URL)
+	if err != nil {
+		reason := invalidReason(err)
+		if reason == ReasonMalformed {
+			// Only the reason is counted, so the details of unexpected errors are logged.
+			f.logger.With(job.LogFields()...).Warnf("URL is invalid: %s", err)
+		} else {
+			f.logger.With(job.LogFields()...).Debugf("URL is invalid: %s", err)
+		}
+		f.recordInvalid(reason)
+		f.reject(job, types.ReportEntry{
+			Row:        job.Row,
+			URL:        job.URL,
+			Status:     types.ReportStatusInvalid,
+			Error:      err.Error(),
+			ErrorClass: failure.ClassInvalid,
+		})
+		return false
+	}
+	job.URL = u.String()
+
+	if rule := f.rules.match(u); rule != "" {
+		f.logger.With(job.LogFields()...).Debugf("URL rejected by filter rule %s", rule)
+		f.stats.filtered.Add(1)
+		f.reject(job, types.ReportEntry{
+			Row:        job.Row,
+			URL:        job.URL,
+			Status:     types.ReportStatusFiltered,
+			Rule:       rule,
+			ErrorClass: failure.ClassFiltered,
+		})
+		return false
+	}
+
+	if f.previous != nil && f.previous.contains(job.URL) {
+		f.logger.With(job.LogFields()...).Debugf("URL already fetched in a previous run")
+		f.stats.previouslyFetched.Add(1)
+		f.reject(job, types.ReportEntry{
+			Row:    job.Row,
+			URL:    job.URL,
+			Status: types.ReportStatusPreviouslyFetched,
+		})
+		return false
+	}
+
+	if f.seen != nil && f.seen.add(job.URL) {
+		f.logger.With(job.LogFields()...).Debugf("URL is a duplicate")
+		f.stats.duplicates.Add(1)
+		f.reject(job, types.ReportEntry{
+			Row:    job.Row,
+			URL:    job.URL,
+			Status: types.ReportStatusDuplicate,
+		})
+		return false
+	}
+
+	return true
+}
+
+// reject reports why the job is not downloaded and ends its span, which fails when the URL was invalid.
+func (f *urlFilter) reject(job *types.Job, entry types.ReportEntry) {
+	f.reporter.Record(entry)
+
+	var err error
+	if entry.Error != "" {
+		err = errors.New(entry.Error)
+	}
+	types.EndJob(job.Context(), entry.Status, err)
+}
+
+// setupDedup prepares the index of seen URLs and, in manifest mode, the URLs of previous runs.
+func (f *urlFilter) setupDedup() error {
+	if f.config.Dedup.Mode == config.DedupModeNone {
+		return nil
+	}
+	f.seen = newSeenIndex(f.config.Dedup)
+
+	if f.config.Dedup.Mode != config.DedupModeManifest {
+		return nil
+	}
+
+	previous, err := loadManifest(f.config.Dedup)
+	if err != nil {
+		return err
+	}
+	f.previous = previous
+	return nil
+}
+
+func (f *urlFilter) recordInvalid(reason string) {
+	f.stats.invalid.Add(1)
+
+	f.stats.mu.Lock()
+	defer f.stats.mu.Unlock()
+	f.stats.invalidReasons[reason]++
+}
+
+// GetURLsChan returns the channel the filter reads jobs from.
+func (f *urlFilter) GetURLsChan() chan *types.Job {
+	return f.in
+}
+
+func (f *urlFilter) GetStats() types.FilterStats {
+	f.stats.mu.Lock()
+	invalidReasons := make(map[string]int32, len(f.stats.invalidReasons))
+	for reason, count := range f.stats.invalidReasons {
+		invalidReasons[reason] = count
+	}
+	f.stats.mu.Unlock()
+
+	return types.FilterStats{
+		Passed:            f.stats.passed.Load(),
+		Filtered:          f.stats.filtered.Load(),
+		Invalid:           f.stats.invalid.Load(),
+		InvalidReasons:    invalidReasons,
+		Duplicates:        f.stats.duplicates.Load(),
+		PreviouslyFetched: f.stats.previouslyFetched.Load(),
+	}
+}
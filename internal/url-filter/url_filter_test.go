@@ -0,0 +1,111 @@
+package urlfilter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/puruabhi/jfrog/home-assignment/internal/config"
+	"github.com/puruabhi/jfrog/home-assignment/internal/failure"
+	"github.com/puruabhi/jfrog/home-assignment/internal/types"
+	typeMocks "github.com/puruabhi/jfrog/home-assignment/internal/types/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func match(t *testing.T, rules *ruleSet, rawURL string) string {
+	u, err := normalizeURL(rawURL)
+	assert.NoError(t, err)
+	return rules.match(u)
+}
+
+func TestMatch(t *testing.T) {
+	rules, err := newRuleSet(config.FilterConfig{
+		AllowHosts:   []string{"example.com", ".example.org"},
+		DenyHosts:    []string{"private.example.com"},
+		DenyPatterns: []string{`\.iso$`},
+		AllowSchemes: []string{"HTTPS"},
+		AllowPorts:   []int{443, 8443},
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"example.com", ""},
+		{"www.example.com/path", ""},
+		{"https://cdn.example.org:8443/file", ""},
+		{"http://example.com", "allowSchemes"},
+		{"https://example.com:9000", "allowPorts"},
+		{"badexample.com", "allowHosts"},
+		{"api.private.example.com", "denyHosts:private.example.com"},
+		{"example.com/images/disk.iso", `denyPatterns:\.iso$`},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, match(t, rules, test.input), test.input)
+	}
+}
+
+func TestMatch_AllowPatterns(t *testing.T) {
+	rules, err := newRuleSet(config.FilterConfig{
+		AllowPatterns: []string{`/api/v[0-9]+/`},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "", match(t, rules, "www.someotherurl.com/api/v1/items"))
+	assert.Equal(t, "allowPatterns", match(t, rules, "www.someotherurl.com/docs"))
+}
+
+func TestNewURLFilter_InvalidPattern(t *testing.T) {
+	filter, err := NewURLFilter(context.Background(), config.FilterConfig{
+		DenyPatterns: []string{"("},
+	}, types.NewLoggerStub(), types.NewReporterStub(), make(chan *types.Job))
+	assert.Error(t, err)
+	assert.Nil(t, filter)
+}
+
+func TestFilter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockReporter := typeMocks.NewMockReportable(ctrl)
+	out := make(chan *types.Job, 10)
+
+	filter, err := NewURLFilter(context.Background(), config.FilterConfig{
+		DenyHosts: []string{"anotherone.com"},
+	}, types.NewLoggerStub(), mockReporter, out)
+	assert.NoError(t, err)
+
+	mockReporter.EXPECT().Record(types.ReportEntry{
+		Row:        2,
+		URL:        "https://www.anotherone.com/",
+		Status:     types.ReportStatusFiltered,
+		Rule:       "denyHosts:anotherone.com",
+		ErrorClass: failure.ClassFiltered,
+	}).Times(1)
+	mockReporter.EXPECT().Record(types.ReportEntry{
+		Row:        4,
+		URL:        "ftp://files.example.com",
+		Status:     types.ReportStatusInvalid,
+		Error:      "unsupported scheme: ftp",
+		ErrorClass: failure.ClassInvalid,
+	}).Times(1)
+
+	filter.GetURLsChan() <- &types.Job{Row: 1, URL: "www.example.com"}
+	filter.GetURLsChan() <- &types.Job{Row: 2, URL: "www.anotherone.com"}
+	filter.GetURLsChan() <- &types.Job{Row: 3, URL: "www.google.com"}
+	filter.GetURLsChan() <- &types.Job{Row: 4, URL: "ftp://files.example.com"}
+	close(filter.GetURLsChan())
+
+	var urls []string
+	for job := range out {
+		urls = append(urls, job.URL)
+	}
+
+	assert.Equal(t, []string{"https://www.example.com/", "https://www.google.com/"}, urls)
+	assert.Equal(t, int32(2), filter.stats.passed.Load())
+	assert.Equal(t, int32(1), filter.stats.filtered.Load())
+	assert.Equal(t, int32(1), filter.stats.invalid.Load())
+	assert.Equal(t, map[string]int32{"unsupported scheme": 1}, filter.stats.invalidReasons)
+}